@@ -0,0 +1,57 @@
+package gracewrap
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestAnnotateOutsideRequestIsNoop(t *testing.T) {
+	Annotate(context.Background(), "tenant", "acme")
+	if got := Annotations(context.Background()); got != nil {
+		t.Fatalf("expected no annotations, got %v", got)
+	}
+}
+
+func TestAnnotateAppearsInLogsAndMetrics(t *testing.T) {
+	var logs bytes.Buffer
+	cfg := DefaultConfig()
+	cfg.EnableMetrics = true
+	cfg.PrometheusRegistry = prometheus.NewRegistry()
+	cfg.AnnotationMetricKeys = []string{"tenant"}
+	cfg.Logger = log.New(&logs, "", 0)
+	g := New(&cfg)
+
+	block := make(chan struct{})
+	started := make(chan struct{})
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Annotate(r.Context(), "tenant", "acme")
+		close(started)
+		<-block
+	}))
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/report", nil))
+	<-started
+
+	g.logActiveRequests()
+	if !strings.Contains(logs.String(), "/report") || !strings.Contains(logs.String(), "tenant=acme") {
+		t.Fatalf("expected annotated request in logs, got %q", logs.String())
+	}
+	close(block)
+
+	// Wait for the request to finish and be counted
+	g.waitForInflight(time.Now().Add(time.Second))
+
+	rr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), `gracewrap_request_annotations_total{key="tenant",value="acme"} 1`) {
+		t.Fatalf("expected annotation counter, got %s", rr.Body.String())
+	}
+}
@@ -0,0 +1,125 @@
+package gracewrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// InflightMetricName is the metric name published by InflightMetricHandler.
+const InflightMetricName = "gracewrap_inflight_requests"
+
+// metricValueList mirrors the custom.metrics.k8s.io/v1beta1 MetricValueList type.
+type metricValueList struct {
+	Kind       string            `json:"kind"`
+	APIVersion string            `json:"apiVersion"`
+	Metadata   map[string]string `json:"metadata"`
+	Items      []metricValue     `json:"items"`
+}
+
+// metricValue mirrors the custom.metrics.k8s.io/v1beta1 MetricValue type.
+type metricValue struct {
+	DescribedObject objectReference `json:"describedObject"`
+	MetricName      string          `json:"metricName"`
+	Timestamp       string          `json:"timestamp"`
+	Value           string          `json:"value"`
+}
+
+// objectReference identifies the pod the metric describes.
+type objectReference struct {
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	APIVersion string `json:"apiVersion"`
+}
+
+// InflightMetricHandler returns an HTTP handler publishing this pod's in-flight
+// request count as a custom.metrics.k8s.io/v1beta1 MetricValueList, so a
+// custom metrics adapter can feed it to a HorizontalPodAutoscaler.
+// The pod is identified through the POD_NAME and POD_NAMESPACE environment
+// variables (set them with the Kubernetes downward API).
+func (g *Graceful) InflightMetricHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := g.inflightCount()
+
+		name := os.Getenv("POD_NAME")
+		if name == "" {
+			name, _ = os.Hostname()
+		}
+
+		list := metricValueList{
+			Kind:       "MetricValueList",
+			APIVersion: "custom.metrics.k8s.io/v1beta1",
+			Metadata:   map[string]string{},
+			Items: []metricValue{{
+				DescribedObject: objectReference{
+					Kind:       "Pod",
+					Namespace:  os.Getenv("POD_NAMESPACE"),
+					Name:       name,
+					APIVersion: "/v1",
+				},
+				MetricName: InflightMetricName,
+				Timestamp:  time.Now().UTC().Format(time.RFC3339),
+				Value:      strconv.FormatInt(n, 10),
+			}},
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(list)
+	})
+}
+
+// TerminationCost describes how disruptive terminating this pod would be
+// right now, for autoscalers choosing scale-in victims.
+type TerminationCost struct {
+	// Inflight is the number of requests being served.
+	Inflight int64 `json:"inflight"`
+	// OldestRequestAge is the age of the oldest in-flight request, in seconds.
+	OldestRequestAge float64 `json:"oldest_request_age_seconds"`
+	// QueuedJobs is the number of jobs waiting in worker pools.
+	QueuedJobs int `json:"queued_jobs"`
+	// Draining reports whether drain has already started.
+	Draining bool `json:"draining"`
+	// Cheap is true when nothing is in flight or queued.
+	Cheap bool `json:"cheap"`
+	// Cost orders pods for scale-in, lowest first; it suits the
+	// controller.kubernetes.io/pod-deletion-cost annotation.
+	Cost int64 `json:"cost"`
+}
+
+// TerminationCost reports how disruptive terminating this pod would be now.
+func (g *Graceful) TerminationCost() TerminationCost {
+	c := TerminationCost{
+		Inflight: g.inflightCount(),
+		Draining: g.draining(),
+	}
+	if active := g.activeRequests(); len(active) > 0 {
+		c.OldestRequestAge = time.Since(active[0].start).Seconds()
+	}
+	g.pools.mu.Lock()
+	for _, p := range g.pools.list {
+		c.QueuedJobs += len(p.jobs)
+	}
+	g.pools.mu.Unlock()
+
+	c.Cheap = c.Inflight == 0 && c.QueuedJobs == 0
+	c.Cost = c.Inflight + int64(c.QueuedJobs) + int64(c.OldestRequestAge)
+	return c
+}
+
+// ScaleInHandler returns an HTTP handler serving TerminationCost as JSON,
+// answering 200 when terminating the pod now is cheap and 409 otherwise, so
+// autoscalers can ask "is it cheap to terminate this pod now?" with a plain
+// status check.
+func (g *Graceful) ScaleInHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c := g.TerminationCost()
+		w.Header().Set("Content-Type", "application/json")
+		if !c.Cheap {
+			w.WriteHeader(http.StatusConflict)
+		}
+		_ = json.NewEncoder(w).Encode(c)
+	})
+}
@@ -0,0 +1,59 @@
+package gracewrap
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestInflightMetricHandler(t *testing.T) {
+	t.Setenv("POD_NAME", "api-0")
+	t.Setenv("POD_NAMESPACE", "prod")
+
+	g := New(nil)
+	g.incInflight()
+	g.incInflight()
+	defer g.decInflight()
+	defer g.decInflight()
+
+	rr := httptest.NewRecorder()
+	g.InflightMetricHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	var list metricValueList
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if list.Kind != "MetricValueList" || len(list.Items) != 1 {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+	item := list.Items[0]
+	if item.Value != "2" || item.DescribedObject.Name != "api-0" || item.DescribedObject.Namespace != "prod" {
+		t.Fatalf("unexpected item: %+v", item)
+	}
+}
+
+func TestScaleInHandler(t *testing.T) {
+	g := New(nil)
+
+	get := func() (int, TerminationCost) {
+		rr := httptest.NewRecorder()
+		g.ScaleInHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/scale-in", nil))
+		var c TerminationCost
+		if err := json.NewDecoder(rr.Body).Decode(&c); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return rr.Code, c
+	}
+
+	if code, c := get(); code != http.StatusOK || !c.Cheap || c.Cost != 0 {
+		t.Fatalf("expected an idle pod to be cheap, got %d %+v", code, c)
+	}
+
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/report")
+	defer done()
+	if code, c := get(); code != http.StatusConflict || c.Cheap || c.Inflight != 1 || c.Cost < 1 {
+		t.Fatalf("expected a busy pod to be costly, got %d %+v", code, c)
+	}
+}
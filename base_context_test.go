@@ -0,0 +1,117 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type baseKey struct{}
+
+func TestRequestContextCancelledAtDrainDeadline(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 100 * time.Millisecond
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	started := make(chan struct{})
+	result := make(chan error, 1)
+	srv := &http.Server{
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), baseKey{}, "kept")
+		},
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Context().Value(baseKey{}) != "kept" {
+				t.Error("expected the server's own BaseContext to be the parent")
+			}
+			close(started)
+			// Simulates a database call that honors its context
+			select {
+			case <-r.Context().Done():
+				result <- r.Context().Err()
+			case <-time.After(5 * time.Second):
+				result <- nil
+			}
+		}),
+	}
+	if err := g.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() { _, _ = http.Get("http://" + ln.Addr().String()) }()
+	<-started
+
+	g.Shutdown()
+	select {
+	case err := <-result:
+		if err == nil {
+			t.Fatal("expected request context to be cancelled")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("request context was not cancelled at the drain deadline")
+	}
+}
+
+func TestBaseContextsReleasedWhenServerStops(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	if err := g.WrapHTTP(srv); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get("http://" + g.Addr("http").String())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+
+	g.baseContexts.mu.Lock()
+	n := len(g.baseContexts.cancels[srv])
+	g.baseContexts.mu.Unlock()
+	if n != 1 {
+		t.Fatalf("expected one base context for the listener, got %d", n)
+	}
+
+	g.Shutdown()
+	g.baseContexts.mu.Lock()
+	n = len(g.baseContexts.cancels)
+	g.baseContexts.mu.Unlock()
+	if n != 0 {
+		t.Fatalf("expected base contexts to be released at shutdown, %d server(s) left", n)
+	}
+}
+
+func TestRequestContextFollowsCommittedDrainDeadline(t *testing.T) {
+	g := New(nil)
+	ctx, cancel := g.untilDrainDeadline(context.Background())
+	defer cancel()
+
+	// The estimate made at drain start is superseded by the deadline servers get
+	g.beginDrain(time.Now().Add(20 * time.Millisecond))
+	deadline := time.Now().Add(150 * time.Millisecond)
+	if !g.commitDrain(deadline) {
+		t.Fatal("expected commit")
+	}
+	if got := g.drainDeadline(); !got.Equal(deadline) {
+		t.Fatalf("expected drain deadline %v, got %v", deadline, got)
+	}
+
+	select {
+	case <-ctx.Done():
+		t.Fatalf("context cancelled %v before the committed drain deadline", time.Until(deadline))
+	case <-time.After(time.Until(deadline) - 30*time.Millisecond):
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected context to be cancelled at the committed drain deadline")
+	}
+}
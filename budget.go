@@ -0,0 +1,33 @@
+package gracewrap
+
+import "time"
+
+// cancelRequestsOverBudget cancels every in-flight request that cannot finish
+// before the drain deadline (see Config.RequestBudget).
+func (g *Graceful) cancelRequestsOverBudget() {
+	if g.config.RequestBudget == nil {
+		return
+	}
+	deadline := g.drainDeadline()
+	for _, e := range g.activeRequests() {
+		g.cancelOverBudget(e, deadline)
+	}
+}
+
+// cancelOverBudget cancels e if its expected completion falls after deadline.
+func (g *Graceful) cancelOverBudget(e *requestEntry, deadline time.Time) {
+	if g.config.RequestBudget == nil {
+		return
+	}
+	budget := g.config.RequestBudget(e.kind, e.method, e.path)
+	if budget <= 0 || !e.start.Add(budget).After(deadline) {
+		return
+	}
+
+	g.logger.Printf("Cancelling %s %s %s: needs %v, %v left before drain deadline",
+		e.kind, e.method, e.path, budget-time.Since(e.start).Round(time.Millisecond), time.Until(deadline).Round(time.Millisecond))
+	e.cancel(ErrDrainDeadlineExceeded)
+	if g.metrics != nil {
+		g.metrics.incBudgetCancelled()
+	}
+}
@@ -0,0 +1,63 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequestsOverBudgetCancelledAtDrain(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 300 * time.Millisecond
+	g.config.RequestBudget = func(kind, method, path string) time.Duration {
+		if path == "/export" {
+			return time.Minute
+		}
+		return 10 * time.Millisecond
+	}
+
+	causes := make(chan error, 2)
+	var started sync.WaitGroup
+	started.Add(2)
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started.Done()
+		select {
+		case <-r.Context().Done():
+			causes <- context.Cause(r.Context())
+		case <-time.After(100 * time.Millisecond):
+			causes <- nil
+		}
+	}))
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/export", nil))
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api", nil))
+	started.Wait()
+
+	g.Shutdown()
+
+	var cancelled, finished int
+	for i := 0; i < 2; i++ {
+		switch err := <-causes; err {
+		case ErrDrainDeadlineExceeded:
+			cancelled++
+		case nil:
+			finished++
+		default:
+			t.Fatalf("unexpected cause %v", err)
+		}
+	}
+	if cancelled != 1 || finished != 1 {
+		t.Fatalf("expected one cancelled and one finished request, got %d and %d", cancelled, finished)
+	}
+
+	mrr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(mrr, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(mrr.Body.String(), "gracewrap_requests_budget_cancelled_total 1") {
+		t.Fatal("expected budget cancellation to be counted")
+	}
+}
@@ -0,0 +1,93 @@
+package gracewrap
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WarmCache is a cache whose warm state survives restarts through a handoff
+// file in Config.CacheDir (an emptyDir or PVC shared across pod restarts).
+type WarmCache interface {
+	// Save writes the warm state; it runs at shutdown once requests drained.
+	Save(w io.Writer) error
+	// Load restores state written by Save in a previous run.
+	Load(r io.Reader) error
+}
+
+// cacheEntry is a registered WarmCache.
+type cacheEntry struct {
+	name  string
+	cache WarmCache
+}
+
+// RegisterCache registers c under name and immediately reloads the state a
+// previous instance saved, if any. Register caches before starting servers
+// so the service is warm by the time it reports ready. A missing handoff file
+// is not an error; a corrupt one is reported and the cache starts cold.
+func (g *Graceful) RegisterCache(name string, c WarmCache) error {
+	if g.config.CacheDir == "" {
+		return errors.New("gracewrap: RegisterCache needs Config.CacheDir")
+	}
+
+	g.caches.mu.Lock()
+	g.caches.list = append(g.caches.list, cacheEntry{name: name, cache: c})
+	g.caches.mu.Unlock()
+
+	f, err := os.Open(g.cacheFile(name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	if err := c.Load(f); err != nil {
+		return fmt.Errorf("gracewrap: loading cache %q: %w", name, err)
+	}
+	g.logger.Printf("Cache %q warmed from handoff file in %v", name, time.Since(start).Round(time.Millisecond))
+	return nil
+}
+
+// cacheFile returns the handoff file path for the named cache.
+func (g *Graceful) cacheFile(name string) string {
+	return filepath.Join(g.config.CacheDir, filepath.Base(name)+".cache")
+}
+
+// saveCaches writes every registered cache to its handoff file. Files are
+// written then renamed, so a crash mid-save never leaves a torn file behind.
+func (g *Graceful) saveCaches() {
+	g.caches.mu.Lock()
+	caches := append([]cacheEntry(nil), g.caches.list...)
+	g.caches.mu.Unlock()
+
+	for _, e := range caches {
+		if err := g.saveCache(e); err != nil {
+			g.logger.Printf("Saving cache %q failed: %v", e.name, err)
+		}
+	}
+}
+
+// saveCache writes one cache to its handoff file.
+func (g *Graceful) saveCache(e cacheEntry) error {
+	path := g.cacheFile(e.name)
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := e.cache.Save(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
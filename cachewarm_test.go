@@ -0,0 +1,46 @@
+package gracewrap
+
+import (
+	"io"
+	"testing"
+)
+
+type stringCache struct{ data string }
+
+func (c *stringCache) Save(w io.Writer) error {
+	_, err := io.WriteString(w, c.data)
+	return err
+}
+
+func (c *stringCache) Load(r io.Reader) error {
+	b, err := io.ReadAll(r)
+	c.data = string(b)
+	return err
+}
+
+func TestCacheHandoffAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := New(nil).RegisterCache("x", &stringCache{}); err == nil {
+		t.Fatal("expected an error without CacheDir")
+	}
+
+	first := New(&Config{CacheDir: dir})
+	hot := &stringCache{}
+	if err := first.RegisterCache("users", hot); err != nil {
+		t.Fatalf("register on cold start: %v", err)
+	}
+	hot.data = "alice,bob"
+	first.config.LoadBalancerDelay = 0
+	first.config.HardStopTimeout = 0
+	first.Shutdown()
+
+	second := New(&Config{CacheDir: dir})
+	warm := &stringCache{}
+	if err := second.RegisterCache("users", warm); err != nil {
+		t.Fatalf("register on warm start: %v", err)
+	}
+	if warm.data != "alice,bob" {
+		t.Fatalf("expected warm state to be reloaded, got %q", warm.data)
+	}
+}
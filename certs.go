@@ -0,0 +1,162 @@
+package gracewrap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// CertManager serves a TLS certificate that can be swapped at runtime
+// without dropping connections. Existing connections keep the certificate
+// they negotiated; new handshakes pick up the reloaded one.
+type CertManager struct {
+	graceful *Graceful
+	load     func() (*tls.Certificate, error)
+	files    []string
+	cert     atomic.Pointer[tls.Certificate]
+
+	mu       sync.Mutex
+	modTimes map[string]time.Time
+}
+
+// NewCertManager loads certFile/keyFile and reloads them when either file
+// changes (polled every CertReloadInterval) or the process receives SIGHUP.
+func (g *Graceful) NewCertManager(certFile, keyFile string) (*CertManager, error) {
+	m := &CertManager{
+		graceful: g,
+		files:    []string{certFile, keyFile},
+		load: func() (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	m.watch()
+	return m, nil
+}
+
+// NewCertManagerFunc uses load to obtain certificates, calling it again on SIGHUP.
+// Use it to plug in certificates from a secret store or an ACME client.
+func (g *Graceful) NewCertManagerFunc(load func() (*tls.Certificate, error)) (*CertManager, error) {
+	m := &CertManager{graceful: g, load: load}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	m.watch()
+	return m, nil
+}
+
+// Reload loads the certificate again and swaps it in atomically.
+// On error the previous certificate stays in use.
+func (m *CertManager) Reload() error {
+	cert, err := m.load()
+	if err != nil {
+		return err
+	}
+	if cert == nil {
+		return errors.New("gracewrap: certificate source returned nil")
+	}
+	m.cert.Store(cert)
+	m.recordModTimes()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (m *CertManager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return m.cert.Load(), nil
+}
+
+// TLSConfig returns a tls.Config serving the managed certificate.
+func (m *CertManager) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: m.GetCertificate}
+}
+
+// watch reloads on SIGHUP and, for file-based managers, on file changes.
+// Reloads are skipped while draining.
+func (m *CertManager) watch() {
+	g := m.graceful
+
+	// Stay subscribed to SIGHUP until shutdown completes: unsubscribing at
+	// drain start would restore its default action and kill the process
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+				if g.draining() {
+					g.logger.Printf("Ignoring SIGHUP certificate reload while draining")
+					continue
+				}
+				m.reloadAndLog("SIGHUP")
+			case <-g.done:
+				return
+			}
+		}
+	}()
+
+	if len(m.files) == 0 {
+		return
+	}
+	interval := g.config.CertReloadInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	g.Every(interval, func(ctx context.Context) error {
+		if m.filesChanged() {
+			m.reloadAndLog("file change")
+		}
+		return nil
+	})
+}
+
+// reloadAndLog reloads the certificate and logs the outcome.
+func (m *CertManager) reloadAndLog(reason string) {
+	if err := m.Reload(); err != nil {
+		m.graceful.logger.Printf("Certificate reload (%s) failed; keeping previous certificate: %v", reason, err)
+		return
+	}
+	m.graceful.logger.Printf("Certificate reloaded (%s)", reason)
+}
+
+// recordModTimes remembers the modification times of the watched files.
+func (m *CertManager) recordModTimes() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.modTimes == nil {
+		m.modTimes = make(map[string]time.Time)
+	}
+	for _, f := range m.files {
+		if fi, err := os.Stat(f); err == nil {
+			m.modTimes[f] = fi.ModTime()
+		}
+	}
+}
+
+// filesChanged reports whether any watched file changed since the last load.
+func (m *CertManager) filesChanged() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, f := range m.files {
+		fi, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if !fi.ModTime().Equal(m.modTimes[f]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package gracewrap
+
+import (
+	"crypto/tls"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestCertManagerReloadsOnFileChange(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir, "first")
+
+	g := New(nil)
+	g.config.CertReloadInterval = 10 * time.Millisecond
+	m, err := g.NewCertManager(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("new cert manager: %v", err)
+	}
+	first, _ := m.GetCertificate(&tls.ClientHelloInfo{})
+
+	// Ensure a distinct mtime, then rotate the files in place
+	time.Sleep(20 * time.Millisecond)
+	writeSelfSignedCert(t, dir, "second")
+	now := time.Now().Add(time.Second)
+	_ = os.Chtimes(certFile, now, now)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		cur, _ := m.GetCertificate(&tls.ClientHelloInfo{})
+		if cur != first {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("certificate was not reloaded after file change")
+}
+
+func TestCertManagerKeepsCertificateOnReloadError(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir, "only")
+
+	g := New(nil)
+	m, err := g.NewCertManager(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("new cert manager: %v", err)
+	}
+	before, _ := m.GetCertificate(nil)
+
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err == nil {
+		t.Fatal("expected reload error")
+	}
+	if after, _ := m.GetCertificate(nil); after != before {
+		t.Fatal("certificate changed after failed reload")
+	}
+}
+
+func TestCertManagerSurvivesSIGHUPWhileDraining(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeSelfSignedCert(t, dir, "only")
+
+	g := New(nil)
+	g.config.LoadBalancerDelay = 5 * time.Second
+	g.config.HardStopTimeout = 0
+	var loads atomic.Int32
+	_, err := g.NewCertManagerFunc(func() (*tls.Certificate, error) {
+		loads.Add(1)
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		return &cert, err
+	})
+	if err != nil {
+		t.Fatalf("new cert manager: %v", err)
+	}
+
+	go g.Shutdown()
+	<-g.drainStarted()
+
+	// Still subscribed, so SIGHUP is ignored rather than killing the process
+	p, _ := os.FindProcess(os.Getpid())
+	_ = p.Signal(syscall.SIGHUP)
+	time.Sleep(50 * time.Millisecond)
+	if n := loads.Load(); n != 1 {
+		t.Fatalf("expected no reload while draining, got %d loads", n)
+	}
+
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+	_ = p.Signal(syscall.SIGHUP)
+	deadline := time.Now().Add(2 * time.Second)
+	for loads.Load() == 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected SIGHUP reload after CancelDrain")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,43 @@
+package gracewrap
+
+import "net/http"
+
+// Placement positions gracewrap's tracking middleware within a chain.
+type Placement int
+
+const (
+	// Outermost tracks everything, including time spent in user middleware
+	// and requests they short-circuit (the WrapHTTP default).
+	Outermost Placement = iota
+	// Innermost tracks only requests that reach the handler, measuring true
+	// handler occupancy.
+	Innermost
+)
+
+// trackedHandler marks a handler chain that already includes tracking, so
+// WrapHTTP does not wrap it a second time.
+type trackedHandler struct {
+	http.Handler
+}
+
+// Chain composes handler with middlewares (mws[0] outermost) and places
+// gracewrap's tracking middleware at the given position. Servers whose
+// Handler comes from Chain are not wrapped again by WrapHTTP.
+func (g *Graceful) Chain(handler http.Handler, placement Placement, mws ...func(http.Handler) http.Handler) http.Handler {
+	return g.ChainFor(nil, handler, placement, mws...)
+}
+
+// ChainFor is Chain for a handler installed on server, whose rejection
+// policy applies (see SetRejectionPolicy).
+func (g *Graceful) ChainFor(server *http.Server, handler http.Handler, placement Placement, mws ...func(http.Handler) http.Handler) http.Handler {
+	if placement == Innermost {
+		handler = g.serverMiddleware(server, handler)
+	}
+	for i := len(mws) - 1; i >= 0; i-- {
+		handler = mws[i](handler)
+	}
+	if placement == Outermost {
+		handler = g.serverMiddleware(server, handler)
+	}
+	return &trackedHandler{Handler: handler}
+}
@@ -0,0 +1,96 @@
+package gracewrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestChainPlacement(t *testing.T) {
+	g := New(nil)
+
+	var sawInflight int64
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	auth := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			g.inflight.mu.Lock()
+			sawInflight = g.inflight.n
+			g.inflight.mu.Unlock()
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	outer := g.Chain(handler, Outermost, auth)
+	outer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if sawInflight != 1 {
+		t.Fatalf("outermost: expected auth to run inside tracking, saw %d inflight", sawInflight)
+	}
+
+	inner := g.Chain(handler, Innermost, auth)
+	inner.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if sawInflight != 0 {
+		t.Fatalf("innermost: expected auth to run outside tracking, saw %d inflight", sawInflight)
+	}
+}
+
+func TestWrapHTTPDoesNotDoubleWrapChain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	chained := g.Chain(http.NotFoundHandler(), Innermost)
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: chained}
+	if err := g.WrapHTTP(srv); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+	if srv.Handler != chained {
+		t.Fatal("expected chained handler to be kept as-is")
+	}
+}
+
+func TestChainUsesServerRejectionPolicy(t *testing.T) {
+	g := newDrainingGraceful(t, nil)
+	srv := &http.Server{}
+	g.SetRejectionPolicy(srv, RejectionPolicy{StatusCode: http.StatusTooManyRequests})
+
+	for _, placement := range []Placement{Outermost, Innermost} {
+		rr := httptest.NewRecorder()
+		g.ChainFor(srv, http.NotFoundHandler(), placement).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+		if rr.Code != http.StatusTooManyRequests {
+			t.Fatalf("placement %v: expected the server's rejection policy, got %d", placement, rr.Code)
+		}
+	}
+}
+
+func TestWrappedChainTrackedOnce(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	var inflight int64
+	chained := g.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inflight = g.inflightCount()
+	}), Innermost)
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.TimeoutHandler(chained, time.Second, "timeout")}
+	if err := g.WrapHTTP(srv); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+
+	resp, err := http.Get("http://" + g.Addr("http").String())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if inflight != 1 {
+		t.Fatalf("expected the request to be tracked once, got %d in flight", inflight)
+	}
+}
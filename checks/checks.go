@@ -0,0 +1,102 @@
+// Package checks provides readiness checks for common dependencies, for use
+// with Graceful.AddReadinessCheck:
+//
+//	g.AddReadinessCheck("postgres", checks.SQL(db))
+//	g.AddReadinessCheck("payments", checks.HTTP("http://payments/healthz", checks.Timeout(2*time.Second)))
+//
+// Every check is bounded by its own timeout (DefaultTimeout unless set with
+// Timeout) as well as by the probe's context.
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultTimeout bounds a check unless Timeout is given.
+const DefaultTimeout = time.Second
+
+// Check reports whether a dependency is usable; nil means ready.
+type Check func(ctx context.Context) error
+
+// Option configures a check.
+type Option func(*options)
+
+type options struct {
+	timeout time.Duration
+	client  *http.Client
+}
+
+// Timeout bounds the check to d instead of DefaultTimeout.
+func Timeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// Client makes HTTP checks use c instead of http.DefaultClient, e.g. for
+// mutual TLS.
+func Client(c *http.Client) Option {
+	return func(o *options) { o.client = c }
+}
+
+// bounded applies the options and wraps fn with the check timeout.
+func bounded(opts []Option, fn func(ctx context.Context, o options) error) Check {
+	o := options{timeout: DefaultTimeout, client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return func(ctx context.Context) error {
+		ctx, cancel := context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+		return fn(ctx, o)
+	}
+}
+
+// Pinger is a database handle that can be pinged, such as *sql.DB or
+// *sql.Conn.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// SQL checks that the database answers a ping.
+func SQL(db Pinger, opts ...Option) Check {
+	return bounded(opts, func(ctx context.Context, _ options) error {
+		return db.PingContext(ctx)
+	})
+}
+
+// TCP checks that addr ("host:port") accepts connections.
+func TCP(addr string, opts ...Option) Check {
+	return bounded(opts, func(ctx context.Context, _ options) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	})
+}
+
+// HTTP checks that a GET of url succeeds with a status from 200 to 399, as
+// a Kubernetes httpGet probe would.
+func HTTP(url string, opts ...Option) Check {
+	return bounded(opts, func(ctx context.Context, o options) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := o.client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,91 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imran31415/gracewrap"
+)
+
+type pinger func(ctx context.Context) error
+
+func (p pinger) PingContext(ctx context.Context) error { return p(ctx) }
+
+func TestSQL(t *testing.T) {
+	if err := SQL(pinger(func(ctx context.Context) error { return nil }))(context.Background()); err != nil {
+		t.Fatalf("expected healthy database, got %v", err)
+	}
+
+	hung := pinger(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	start := time.Now()
+	err := SQL(hung, Timeout(20*time.Millisecond))(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the check timeout, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatal("expected the check to give up at its timeout")
+	}
+}
+
+func TestTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	if err := TCP(addr)(context.Background()); err != nil {
+		t.Fatalf("expected open port, got %v", err)
+	}
+	ln.Close()
+	if err := TCP(addr)(context.Background()); err == nil {
+		t.Fatal("expected a closed port to fail")
+	}
+}
+
+func TestHTTP(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	if err := HTTP(srv.URL)(context.Background()); err != nil {
+		t.Fatalf("expected healthy endpoint, got %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := HTTP(srv.URL)(context.Background()); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Fatalf("expected 503 to fail the check, got %v", err)
+	}
+	if err := HTTP(srv.URL+"/slow", Timeout(20*time.Millisecond))(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the check timeout, got %v", err)
+	}
+}
+
+func TestRegisteredAsReadinessCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	g := gracewrap.New(nil)
+	g.AddReadinessCheck("cache", TCP(ln.Addr().String()))
+	rr := httptest.NewRecorder()
+	g.HealthHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d %q", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,52 @@
+package gracewrap
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock audit parameters.
+const (
+	clockAuditInterval = 250 * time.Millisecond
+	clockJumpThreshold = 500 * time.Millisecond
+)
+
+// wallNow returns the wall clock reading only (no monotonic component).
+var wallNow = func() time.Time { return time.Now().Round(0) }
+
+// auditClock watches for wall-clock jumps and suspends during drain until the
+// returned func is called. gracewrap's deadlines are derived from monotonic
+// readings, so they stay correct; the audit logs and emits EventClockJump
+// whenever a wall-clock based budget would have misfired.
+func (g *Graceful) auditClock() (stop func()) {
+	done, exited := make(chan struct{}), make(chan struct{})
+	var jumps atomic.Int64
+	go func() {
+		defer close(exited)
+		ticker := time.NewTicker(clockAuditInterval)
+		defer ticker.Stop()
+		lastMono, lastWall := time.Now(), wallNow()
+		for {
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+			mono, wall := time.Now(), wallNow()
+			skew := wall.Sub(lastWall) - mono.Sub(lastMono)
+			if skew > clockJumpThreshold || skew < -clockJumpThreshold {
+				jumps.Add(1)
+				g.logger.Printf("Wall clock jumped by %v during drain; deadlines use monotonic time", skew.Round(time.Millisecond))
+				g.emit(EventClockJump, "wall clock jumped by %v", skew.Round(time.Millisecond))
+			}
+			lastMono, lastWall = mono, wall
+		}
+	}()
+	return func() {
+		close(done)
+		<-exited
+		if n := jumps.Load(); n > 0 {
+			g.updateReport(func(r *ShutdownReport) { r.ClockJumps = int(n) })
+		}
+	}
+}
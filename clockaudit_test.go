@@ -0,0 +1,47 @@
+package gracewrap
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClockAuditDetectsWallClockJump(t *testing.T) {
+	var offset atomic.Int64
+	orig := wallNow
+	wallNow = func() time.Time { return time.Now().Round(0).Add(time.Duration(offset.Load())) }
+	defer func() { wallNow = orig }()
+
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.ClockAudit = true
+	g.OnShutdown("slow", func(ctx context.Context) error {
+		time.Sleep(2 * clockAuditInterval)
+		// NTP steps the clock an hour forward mid-drain
+		offset.Store(int64(time.Hour))
+		time.Sleep(2 * clockAuditInterval)
+		return nil
+	})
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+	g.Shutdown()
+
+	if r := g.Report(); r == nil || r.ClockJumps != 1 {
+		t.Fatalf("expected one clock jump in the report, got %+v", r)
+	}
+	for {
+		e := <-events
+		if e.Type == EventClockJump {
+			if e.Code != CodeClockJump {
+				t.Fatalf("unexpected code %s", e.Code)
+			}
+			return
+		}
+		if e.Type == EventShutdownCompleted {
+			t.Fatal("expected a clock_jump event")
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package gracewrap
 
 import (
+	"io"
 	"log"
+	"net/http"
 	"os"
 	"strconv"
 	"time"
@@ -15,6 +17,9 @@ type Config struct {
 	DrainTimeout time.Duration
 	// Hard stop timeout after drain ends (acts as a final safety deadline).
 	HardStopTimeout time.Duration
+	// What happens to requests still in flight when DrainTimeout expires
+	// (defaults to DrainTimeoutAbandon)
+	DrainTimeoutPolicy DrainTimeoutPolicy
 	// How long to wait for load balancers/service mesh to notice readiness change.
 	// This prevents race conditions where new traffic is routed during shutdown.
 	LoadBalancerDelay time.Duration
@@ -26,17 +31,185 @@ type Config struct {
 	PrometheusGatherer prometheus.Gatherer
 	// Enable Prometheus metrics (defaults to false)
 	EnableMetrics bool
+	// Annotation keys (see Annotate) exported as labels on
+	// gracewrap_request_annotations_total. Keys not listed are only logged.
+	AnnotationMetricKeys []string
+	// Optional store for session state handed off at drain (see RegisterSession)
+	SessionStore SessionStore
+	// Reject HTTP requests and RPCs that arrive after drain has started
+	RejectWhileDraining bool
+	// Response given to rejected requests (defaults to 503 / codes.Unavailable)
+	Rejection RejectionPolicy
+	// Requests for which DrainBypass returns true (e.g. carrying a canary or
+	// deployment-verification header) are still served while draining
+	DrainBypass func(r *http.Request) bool
+	// Optional forwarding of rejected idempotent requests to another replica
+	DrainRetry *DrainRetryConfig
+	// How often CertManager polls certificate files for changes (defaults to 10s)
+	CertReloadInterval time.Duration
+	// Minimum timeout given to a shutdown hook without an explicit timeout
+	// when the remaining budget is divided among hooks (defaults to 1s)
+	HookTimeoutFloor time.Duration
+	// What to do with request bodies still uploading when drain starts
+	UploadDrainPolicy UploadDrainPolicy
+	// How long uploads may continue after drain starts with UploadDrainDeadline
+	UploadDrainTimeout time.Duration
+	// Serve cleartext HTTP/2 (h2c) on wrapped HTTP servers and send GOAWAY
+	// on those connections as soon as drain starts
+	EnableH2C bool
+	// Default position of gracewrap's interceptors in the chains built by
+	// NewGRPCServer and ServeGRPC (defaults to Outermost); see
+	// WithInterceptorPlacement
+	InterceptorPlacement Placement
+	// Record gracewrap_grpc_method_requests_total and
+	// gracewrap_grpc_request_duration_seconds by full method and status code
+	GRPCMethodMetrics bool
+	// Attach a "grpc-drain: true" trailer to RPCs that end while draining,
+	// so clients can tell a draining backend from a failing one
+	GRPCDrainTrailers bool
+	// Convert RPCs cut short by drain (their context cancelled by a request
+	// budget, DrainTimeoutPolicy or the drain deadline) into UNAVAILABLE with
+	// a zero grpc-retry-pushback-ms trailer, so retry-enabled clients retry
+	// on another backend instead of surfacing a cancellation
+	GRPCDrainUnavailable bool
+	// Send GOAWAY on gRPC connections as soon as drain starts instead of
+	// after the load balancer delay, so long-lived client connections stop
+	// opening new streams immediately. The gRPC listeners close at the same
+	// time, so only enable it when clients balance across replicas
+	// themselves (client-side or xDS load balancing)
+	GRPCGoAwayOnDrain bool
+	// Address of the Envoy sidecar's admin endpoint (e.g. "127.0.0.1:15000"
+	// for Istio). When set, drain POSTs /healthcheck/fail and then
+	// /drain_listeners?graceful to it before the load balancer delay, so the
+	// sidecar stops accepting before the app does
+	EnvoyAdminAddr string
+	// Disable HTTP keep-alives on tracked servers as soon as drain starts, so
+	// persistent connections are closed after their current request
+	DisableKeepAlivesOnDrain bool
+	// Header set to "true" on responses served while draining, alongside
+	// Connection: close (defaults to X-Draining; empty disables it)
+	DrainingHeader string
+	// Skip the load balancer delay and hard stop wait when no request was
+	// ever tracked, so batch-style pods that never serve traffic exit quickly
+	SkipDelaysWithoutTraffic bool
+	// How long WebSocket clients get to close after the Going Away frame
+	// before their connections are closed (defaults to 5s)
+	WebsocketCloseGrace time.Duration
+	// Log every lifecycle event as a JSON line carrying its stable code
+	// (GW001, ...) for log-based alerting
+	StructuredLogs bool
+	// Length of the time slices drain statistics are recorded in
+	// (defaults to 10s)
+	DrainStatsInterval time.Duration
+	// Expected duration of a request ("http"/"grpc", method, path). When set,
+	// once drain begins requests that cannot finish before the drain deadline
+	// are cancelled with ErrDrainDeadlineExceeded instead of being killed at
+	// hard stop. Return 0 for requests that should never be cancelled early.
+	RequestBudget func(kind, method, path string) time.Duration
+	// Per-route drain behavior; the first matching policy applies
+	RoutePolicies []RouteDrainPolicy
+	// Paths (or gRPC methods) served without in-flight tracking, e.g.
+	// "/health/*", "/metrics", "/debug/pprof/*", so probes and scrapes
+	// neither inflate the in-flight count nor delay the drain
+	UntrackedPaths []string
+	// Shed requests with 429 (ResourceExhausted for gRPC) once this many are
+	// in flight; 0 disables the limit
+	MaxInflight int
+	// Lower in-flight limit applied once drain starts, answered with 503, to
+	// keep drains bounded on an overloaded pod; 0 keeps MaxInflight
+	MaxInflightDuringDrain int
+	// Directory for cache handoff files (see RegisterCache), e.g. an emptyDir
+	CacheDir string
+	// Upper bounds for per-pod jitter added before drain starts and to the
+	// load balancer delay, so mass terminations don't close connections to
+	// shared backends in synchronized waves
+	DrainStartJitter        time.Duration
+	LoadBalancerDelayJitter time.Duration
+	// Watch for wall-clock jumps and suspends during drain, logging when a
+	// wall-clock based budget would have misfired
+	ClockAudit bool
+	// Route template of a completed HTTP request (e.g. "/users/{id}"), used
+	// to label per-route request metrics without raw-URL cardinality. It
+	// sees the request as passed to the handler; routers that only know the
+	// template inside can report it with SetRoute instead
+	RouteLabelFunc func(r *http.Request) string
+	// Path of the liveness probe on wrapped servers (e.g. "/health/live").
+	// It is answered before drain rejection and tracking, and kept answering
+	// 200 on the server's address after the server closes its listener,
+	// until shutdown completes, so the kubelet never restarts a container
+	// mid-drain when liveness and readiness share a port
+	LivenessPath string
+	// Make LivenessHandler return 500 once shutdown has completed
+	LivenessFailAfterShutdown bool
+	// Called synchronously as each tracked HTTP request or RPC starts and
+	// ends, to feed concurrency trackers, audit logs or adaptive limiters
+	// from the same accounting gracewrap drains on. Keep them fast
+	OnRequestStart func(RequestInfo)
+	OnRequestEnd   func(RequestInfo)
+	// Directory where a heap snapshot and a CPU profile are written when
+	// drain starts; their paths are listed in the shutdown report
+	ProfileDir string
+	// How long the drain CPU profile runs (defaults to 5s; it also stops
+	// when shutdown completes)
+	ProfileDuration time.Duration
+	// Don't log every goroutine stack when the drain deadline passes with
+	// requests still in flight
+	DisableStallDump bool
+	// File a goroutine profile (pprof format) is written to when the drain
+	// deadline passes with requests still in flight, e.g. on an emptyDir
+	StallProfilePath string
+	// Run readiness checks (see AddReadinessCheck) in the background at this
+	// interval and answer probes from the latest results, so frequent probes
+	// don't hit dependencies; 0 runs them on each probe
+	ReadinessCheckInterval time.Duration
+	// Bound on one run of the readiness checks (defaults to 5s)
+	ReadinessCheckTimeout time.Duration
+	// Signals that start graceful shutdown in Wait (defaults to SIGTERM and
+	// SIGINT). Other signals can be given actions with OnSignal
+	ShutdownSignals []os.Signal
+	// Keep draining when another shutdown signal arrives during shutdown,
+	// instead of escalating to ForceShutdown (as pressing Ctrl+C twice does)
+	DisableSignalEscalation bool
+	// Handle SIGQUIT by logging every goroutine stack and the in-flight
+	// requests instead of Go's default dump-and-exit, to diagnose a pod that
+	// refuses to drain without killing it
+	DumpOnSIGQUIT bool
+	// Platform termination triggers watched by Wait in addition to the
+	// shutdown signals (see FileSource, ANCMSource, TerminationFunc)
+	TerminationSources []TerminationSource
+	// Where the shutdown report (see Report) is written as a JSON line when
+	// shutdown completes, e.g. os.Stdout for the log pipeline
+	ReportWriter io.Writer
+	// Also log the shutdown report as JSON when shutdown completes
+	LogReport bool
+	// The pod's terminationGracePeriodSeconds (set it from the same value in
+	// the pod spec). New warns when the load balancer delay, drain and hard
+	// stop timeouts plus jitter don't fit inside it with GracePeriodMargin
+	// to spare, since the kubelet would SIGKILL the process mid-drain
+	TerminationGracePeriod time.Duration
+	// Part of TerminationGracePeriod kept free of shutdown work (defaults to
+	// 10% of it, at least 1s)
+	GracePeriodMargin time.Duration
+	// Derive DrainTimeout and HardStopTimeout (and cap LoadBalancerDelay)
+	// from TerminationGracePeriod instead of warning
+	AutoTuneTimeouts bool
+	// File recording how long recent drains took (e.g. on an emptyDir or
+	// PVC). At startup gracewrap warns when most of them used more than 80%
+	// of DrainTimeout, so budgets can be raised before requests get dropped
+	DrainHistoryFile string
 }
 
 // DefaultConfig returns a Config with sensible defaults.
 func DefaultConfig() Config {
 	return Config{
-		DrainTimeout:       25 * time.Second,
-		HardStopTimeout:    5 * time.Second,
-		LoadBalancerDelay:  1 * time.Second,
-		EnableMetrics:      false,
-		PrometheusRegistry: nil,
-		PrometheusGatherer: nil,
+		DrainTimeout:             25 * time.Second,
+		HardStopTimeout:          5 * time.Second,
+		LoadBalancerDelay:        1 * time.Second,
+		EnableMetrics:            false,
+		PrometheusRegistry:       nil,
+		PrometheusGatherer:       nil,
+		DisableKeepAlivesOnDrain: true,
+		DrainingHeader:           "X-Draining",
 	}
 }
 
@@ -65,6 +238,20 @@ func ConfigFromEnv() Config {
 		}
 	}
 
+	// Parse TERMINATION_GRACE_PERIOD_SECONDS
+	if val := os.Getenv("TERMINATION_GRACE_PERIOD_SECONDS"); val != "" {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds > 0 {
+			cfg.TerminationGracePeriod = time.Duration(seconds) * time.Second
+		}
+	}
+
+	// Parse AUTO_TUNE_TIMEOUTS
+	if val := os.Getenv("AUTO_TUNE_TIMEOUTS"); val != "" {
+		if enable, err := strconv.ParseBool(val); err == nil {
+			cfg.AutoTuneTimeouts = enable
+		}
+	}
+
 	// Parse ENABLE_METRICS
 	if val := os.Getenv("ENABLE_METRICS"); val != "" {
 		if enable, err := strconv.ParseBool(val); err == nil {
@@ -72,5 +259,58 @@ func ConfigFromEnv() Config {
 		}
 	}
 
+	// Parse DISABLE_KEEPALIVES_ON_DRAIN
+	if val := os.Getenv("DISABLE_KEEPALIVES_ON_DRAIN"); val != "" {
+		if disable, err := strconv.ParseBool(val); err == nil {
+			cfg.DisableKeepAlivesOnDrain = disable
+		}
+	}
+
+	// Parse CACHE_DIR
+	if val := os.Getenv("CACHE_DIR"); val != "" {
+		cfg.CacheDir = val
+	}
+
+	// Parse ENVOY_ADMIN_ADDR
+	if val := os.Getenv("ENVOY_ADMIN_ADDR"); val != "" {
+		cfg.EnvoyAdminAddr = val
+	}
+
+	// Parse DRAIN_HISTORY_FILE
+	if val := os.Getenv("DRAIN_HISTORY_FILE"); val != "" {
+		cfg.DrainHistoryFile = val
+	}
+
+	// Parse PROFILE_DIR
+	if val := os.Getenv("PROFILE_DIR"); val != "" {
+		cfg.ProfileDir = val
+	}
+
+	// Parse STALL_PROFILE_PATH
+	if val := os.Getenv("STALL_PROFILE_PATH"); val != "" {
+		cfg.StallProfilePath = val
+	}
+
+	// Parse DUMP_ON_SIGQUIT
+	if val := os.Getenv("DUMP_ON_SIGQUIT"); val != "" {
+		if enable, err := strconv.ParseBool(val); err == nil {
+			cfg.DumpOnSIGQUIT = enable
+		}
+	}
+
+	// Parse LOG_SHUTDOWN_REPORT
+	if val := os.Getenv("LOG_SHUTDOWN_REPORT"); val != "" {
+		if enable, err := strconv.ParseBool(val); err == nil {
+			cfg.LogReport = enable
+		}
+	}
+
+	// Parse STRUCTURED_LOGS
+	if val := os.Getenv("STRUCTURED_LOGS"); val != "" {
+		if enable, err := strconv.ParseBool(val); err == nil {
+			cfg.StructuredLogs = enable
+		}
+	}
+
 	return cfg
 }
@@ -0,0 +1,146 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// connTracker follows http.Server connection states so idle keep-alive
+// connections can be closed as soon as drain begins.
+//
+// It also owns the requests served on each connection: once the server is
+// done with a connection (idle or closed) any request still counted on it was
+// abandoned by a wrapper such as http.TimeoutHandler, and is released so the
+// in-flight count cannot leak upward and wedge the drain.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]http.ConnState
+	owned map[net.Conn]map[*hijackWriter]struct{}
+}
+
+// connKey is the context key under which the serving connection is stored.
+type connKey struct{}
+
+// setConnContext records the serving connection in each request context,
+// chaining any existing ConnContext.
+func (g *Graceful) setConnContext(server *http.Server) {
+	prev := server.ConnContext
+	server.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if prev != nil {
+			ctx = prev(ctx, c)
+		}
+		return context.WithValue(ctx, connKey{}, c)
+	}
+}
+
+// own records that hw is a request served on c.
+func (t *connTracker) own(c net.Conn, hw *hijackWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.owned == nil {
+		t.owned = make(map[net.Conn]map[*hijackWriter]struct{})
+	}
+	if t.owned[c] == nil {
+		t.owned[c] = make(map[*hijackWriter]struct{})
+	}
+	t.owned[c][hw] = struct{}{}
+}
+
+// disown forgets hw once its handler has returned.
+func (t *connTracker) disown(c net.Conn, hw *hijackWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.owned[c], hw)
+	if len(t.owned[c]) == 0 {
+		delete(t.owned, c)
+	}
+}
+
+// release removes and returns the requests still owned by c.
+func (t *connTracker) release(c net.Conn) []*hijackWriter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]*hijackWriter, 0, len(t.owned[c]))
+	for hw := range t.owned[c] {
+		out = append(out, hw)
+	}
+	delete(t.owned, c)
+	return out
+}
+
+// trackConnState installs a ConnState hook on server, chaining any existing one.
+func (g *Graceful) trackConnState(server *http.Server) {
+	prev := server.ConnState
+	server.ConnState = func(c net.Conn, state http.ConnState) {
+		prevState, known := g.conns.update(c, state)
+		if g.metrics != nil {
+			g.metrics.observeConnState(prevState, known, state)
+		}
+		if state == http.StateIdle || state == http.StateClosed {
+			if abandoned := g.conns.release(c); len(abandoned) > 0 {
+				g.logger.Printf("Releasing %d abandoned request(s) on %s", len(abandoned), c.RemoteAddr())
+				for _, hw := range abandoned {
+					hw.finish()
+				}
+			}
+		}
+		// Connections going idle after drain started are not worth keeping
+		if state == http.StateIdle && g.draining() {
+			_ = c.Close()
+		}
+		if prev != nil {
+			prev(c, state)
+		}
+	}
+}
+
+// update records the latest state of c and returns its previous state.
+func (t *connTracker) update(c net.Conn, state http.ConnState) (prev http.ConnState, known bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conns == nil {
+		t.conns = make(map[net.Conn]http.ConnState)
+	}
+	prev, known = t.conns[c]
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(t.conns, c)
+	default:
+		t.conns[c] = state
+	}
+	return prev, known
+}
+
+// counts returns the number of tracked connections per state.
+func (t *connTracker) counts() map[http.ConnState]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make(map[http.ConnState]int)
+	for _, s := range t.conns {
+		out[s]++
+	}
+	return out
+}
+
+// closeIdle closes every idle connection and returns how many were closed.
+func (t *connTracker) closeIdle() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := 0
+	for c, s := range t.conns {
+		if s == http.StateIdle {
+			_ = c.Close()
+			n++
+		}
+	}
+	return n
+}
+
+// reapIdleConns closes idle keep-alive connections at drain start.
+func (g *Graceful) reapIdleConns() {
+	if n := g.conns.closeIdle(); n > 0 {
+		g.logger.Printf("Closed %d idle HTTP connection(s)", n)
+	}
+}
@@ -0,0 +1,53 @@
+package gracewrap
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdleConnectionsReapedAtDrain(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 100 * time.Millisecond
+	g.config.HardStopTimeout = 0
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+	if err := g.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatal(err)
+	}
+
+	// Make one request over a raw keep-alive connection, leaving it idle
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("GET / HTTP/1.1\r\nHost: x\r\n\r\n"))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	time.Sleep(20 * time.Millisecond)
+
+	if n := g.conns.counts()[http.StateIdle]; n != 1 {
+		t.Fatalf("expected 1 idle connection, got %d", n)
+	}
+
+	// Shutdown sits in the load balancer delay; the idle conn must already be closed
+	go g.Shutdown()
+	_ = conn.SetReadDeadline(time.Now().Add(80 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("expected idle connection to be closed during drain, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package gracewrap
+
+import "context"
+
+// Done returns a channel that is closed when drain begins, for application
+// code that selects on shutdown instead of polling Ready:
+//
+//	select {
+//	case job := <-jobs:
+//		process(job)
+//	case <-g.Done():
+//		return
+//	}
+//
+// If the drain is cancelled (see CancelDrain), later calls return a new
+// channel for the next drain.
+func (g *Graceful) Done() <-chan struct{} {
+	return g.drainStarted()
+}
+
+// Context returns a context that is cancelled when drain begins, so
+// background work started with it stops taking on new work. Every call
+// before drain returns the same context; like Done, calls after a cancelled
+// drain return a new one for the next drain.
+func (g *Graceful) Context() context.Context {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	if g.drain.ctx == nil {
+		g.drain.ctx, g.drain.ctxCancel = context.WithCancel(context.Background())
+		select {
+		case <-g.drain.ch:
+			g.drain.ctxCancel()
+		default:
+		}
+	}
+	return g.drain.ctx
+}
+
+// HardContext returns a context that is cancelled at the drain deadline, or
+// when shutdown completes if that comes first: the point after which
+// in-flight work is abandoned. Use it for work that should keep running
+// through the drain, such as flushing what Context's cancellation left
+// behind.
+func (g *Graceful) HardContext() context.Context {
+	ctx, cancel := g.untilDrainDeadline(context.Background())
+	go func() {
+		select {
+		case <-g.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx
+}
@@ -0,0 +1,85 @@
+package gracewrap
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDoneAndContexts(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 100 * time.Millisecond
+	g.config.DrainTimeout = time.Second
+	g.config.HardStopTimeout = 0
+
+	ctx, hard := g.Context(), g.HardContext()
+	select {
+	case <-g.Done():
+		t.Fatal("Done closed before drain")
+	default:
+	}
+
+	finished := make(chan struct{})
+	go func() {
+		g.Shutdown()
+		close(finished)
+	}()
+
+	select {
+	case <-g.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Done not closed at drain start")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Context not cancelled at drain start")
+	}
+	if hard.Err() != nil {
+		t.Fatal("HardContext cancelled before the drain deadline")
+	}
+
+	<-finished
+	select {
+	case <-hard.Done():
+	case <-time.After(time.Second):
+		t.Fatal("HardContext not cancelled when shutdown completed")
+	}
+}
+
+func TestContextSharedPerDrain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 5 * time.Second
+	g.config.HardStopTimeout = 0
+
+	before := runtime.NumGoroutine()
+	ctx := g.Context()
+	for i := 0; i < 100; i++ {
+		if g.Context() != ctx {
+			t.Fatal("expected the same context before drain")
+		}
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("Context started %d goroutine(s)", n-before)
+	}
+
+	go g.Shutdown()
+	<-ctx.Done()
+	if g.Context().Err() == nil {
+		t.Fatal("expected Context to be cancelled while draining")
+	}
+
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+	next := g.Context()
+	if next == ctx || next.Err() != nil {
+		t.Fatal("expected a fresh context after CancelDrain")
+	}
+
+	g.config.LoadBalancerDelay = 0
+	g.Shutdown()
+	if next.Err() == nil {
+		t.Fatal("expected the fresh context to be cancelled by the next drain")
+	}
+}
@@ -0,0 +1,255 @@
+package gracewrap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Built-in shutdown node names for the tracked servers.
+const (
+	// NodeHTTP groups every tracked HTTP server.
+	NodeHTTP = "http"
+	// NodeGRPC groups every tracked gRPC server.
+	NodeGRPC = "grpc"
+)
+
+// hook is a named function run during shutdown.
+type hook struct {
+	name     string
+	timeout  time.Duration // zero means derived from the remaining budget
+	critical bool          // also run by ForceShutdown
+	fn       func(ctx context.Context) error
+}
+
+// stopFunc stops a single component within the drain deadline carried by ctx.
+type stopFunc func(ctx context.Context)
+
+// OnShutdown registers fn to run during shutdown under the given node name.
+// Unless ordered with After, hooks run concurrently with server shutdown.
+// The context passed to fn is bounded by an equal share of the remaining
+// drain budget (see Config.HookTimeoutFloor and OnShutdownTimeout).
+func (g *Graceful) OnShutdown(name string, fn func(ctx context.Context) error) {
+	g.order.mu.Lock()
+	defer g.order.mu.Unlock()
+	g.order.hooks = append(g.order.hooks, hook{name: name, fn: fn})
+}
+
+// OnShutdownTimeout is like OnShutdown but bounds fn to timeout instead of
+// a share of the remaining shutdown budget.
+func (g *Graceful) OnShutdownTimeout(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	g.order.mu.Lock()
+	defer g.order.mu.Unlock()
+	g.order.hooks = append(g.order.hooks, hook{name: name, timeout: timeout, fn: fn})
+}
+
+// OnShutdownCritical is like OnShutdownTimeout, and fn also runs when
+// shutdown is forced (see ForceShutdown), with its own timeout rather than
+// what is left of the drain budget. Use it for the few steps that must
+// happen before the process dies, such as flushing a write-ahead log.
+func (g *Graceful) OnShutdownCritical(name string, timeout time.Duration, fn func(ctx context.Context) error) {
+	g.order.mu.Lock()
+	defer g.order.mu.Unlock()
+	g.order.hooks = append(g.order.hooks, hook{name: name, timeout: timeout, critical: true, fn: fn})
+}
+
+// After declares that the node named then must not start stopping until the
+// node named first has stopped, e.g. After(NodeHTTP, "kafka-producer") stops
+// ingest before the producer it writes to. Nodes are server groups (NodeHTTP,
+// NodeGRPC), server names (see SetServerName) or hook names; names may be
+// registered later. A group covers its named servers too, unless they are
+// ordered after it: After(NodeHTTP, "metrics") stops the metrics server once
+// the other HTTP servers have. After returns an
+// error if the dependency would create a cycle.
+func (g *Graceful) After(first, then string) error {
+	g.order.mu.Lock()
+	defer g.order.mu.Unlock()
+
+	if first == then || g.dependsOnLocked(first, then) {
+		return fmt.Errorf("gracewrap: ordering %q after %q creates a cycle", then, first)
+	}
+	if g.order.deps == nil {
+		g.order.deps = make(map[string][]string)
+	}
+	g.order.deps[then] = append(g.order.deps[then], first)
+	return nil
+}
+
+// dependsOnLocked reports whether node transitively depends on target.
+func (g *Graceful) dependsOnLocked(node, target string) bool {
+	return dependsOn(g.order.deps, node, target)
+}
+
+// dependsOn reports whether node transitively depends on target in deps.
+func dependsOn(deps map[string][]string, node, target string) bool {
+	seen := map[string]bool{}
+	stack := []string{node}
+	for len(stack) > 0 {
+		n := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, dep := range deps[n] {
+			if dep == target {
+				return true
+			}
+			if !seen[dep] {
+				seen[dep] = true
+				stack = append(stack, dep)
+			}
+		}
+	}
+	return false
+}
+
+// ShutdownOrder stops the given nodes one after another, e.g.
+// ShutdownOrder("public-api", "internal", "metrics") stops the public HTTP
+// server first, then the internal gRPC server, and keeps the metrics server
+// scrapeable until last. It is shorthand for After on each consecutive pair;
+// nodes are server names (see SetServerName), server groups or hook names.
+func (g *Graceful) ShutdownOrder(nodes ...string) error {
+	for i := 1; i < len(nodes); i++ {
+		if err := g.After(nodes[i-1], nodes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runOrdered stops every node, starting each one only after the nodes it
+// depends on have finished. Nodes without dependencies stop concurrently.
+// A group node (e.g. NodeHTTP) also finishes only after its members (named
+// servers) have, except members ordered after the group itself.
+func (g *Graceful) runOrdered(ctx context.Context, nodes map[string][]stopFunc, groups map[string][]string) {
+	deps := g.nodeDeps(nodes, groups)
+
+	done := make(map[string]chan struct{}, len(nodes))
+	for name := range nodes {
+		done[name] = make(chan struct{})
+	}
+
+	var wg sync.WaitGroup
+	for name, stops := range nodes {
+		wg.Add(1)
+		go func(name string, stops []stopFunc) {
+			defer wg.Done()
+			defer close(done[name])
+
+			for _, dep := range deps[name] {
+				if ch, ok := done[dep]; ok {
+					<-ch
+				}
+			}
+
+			var inner sync.WaitGroup
+			for _, stop := range stops {
+				inner.Add(1)
+				go func(stop stopFunc) {
+					defer inner.Done()
+					stop(ctx)
+				}(stop)
+			}
+			inner.Wait()
+		}(name, stops)
+	}
+	wg.Wait()
+}
+
+// nodeDeps returns the dependencies between nodes: those declared with After
+// plus each group's dependency on its members. Groups whose servers are all
+// named are added to nodes.
+func (g *Graceful) nodeDeps(nodes map[string][]stopFunc, groups map[string][]string) map[string][]string {
+	g.order.mu.Lock()
+	deps := make(map[string][]string, len(g.order.deps))
+	for k, v := range g.order.deps {
+		deps[k] = append([]string(nil), v...)
+	}
+	g.order.mu.Unlock()
+	for group, members := range groups {
+		if _, ok := nodes[group]; !ok {
+			// Every server in the group is named; keep the group as a node
+			nodes[group] = nil
+		}
+		for _, m := range members {
+			if m != group && !dependsOn(deps, m, group) {
+				deps[group] = append(deps[group], m)
+			}
+		}
+	}
+	return deps
+}
+
+// hookNodes adds the stop functions for registered hooks to nodes, keyed by name.
+// Hooks without an explicit timeout share the budget left until deadline.
+func (g *Graceful) hookNodes(nodes map[string][]stopFunc, deadline time.Time) {
+	g.order.mu.Lock()
+	hooks := append([]hook(nil), g.order.hooks...)
+	g.order.mu.Unlock()
+
+	budgets := g.hookBudgets(hooks, time.Until(deadline))
+	g.updateReport(func(r *ShutdownReport) { r.HookBudgets = budgets })
+
+	forced := g.cut.forced.Load()
+	for _, h := range hooks {
+		h := h
+		if forced && !h.critical {
+			continue
+		}
+		budget := budgets[h.name]
+		nodes[h.name] = append(nodes[h.name], func(ctx context.Context) {
+			if forced {
+				// The shutdown context is already cancelled
+				ctx = context.WithoutCancel(ctx)
+			}
+			ctx, cancel := context.WithTimeout(ctx, budget)
+			defer cancel()
+			start := time.Now()
+			err := h.fn(ctx)
+			res := HookResult{Name: h.name, Duration: time.Since(start)}
+			if err != nil {
+				g.logger.Printf("Shutdown hook %q error: %v", h.name, err)
+				res.Error = err.Error()
+			} else {
+				g.logger.Printf("Shutdown hook %q completed", h.name)
+			}
+			if g.metrics != nil {
+				g.metrics.observeShutdownStep("hook:"+h.name, res.Duration)
+			}
+			g.updateReport(func(r *ShutdownReport) { r.Hooks = append(r.Hooks, res) })
+		})
+	}
+}
+
+// hookBudgets computes each hook's timeout: explicit timeouts are kept and the
+// rest of the remaining budget is divided equally, with a floor.
+func (g *Graceful) hookBudgets(hooks []hook, remaining time.Duration) map[string]time.Duration {
+	floor := g.config.HookTimeoutFloor
+	if floor <= 0 {
+		floor = time.Second
+	}
+
+	budgets := make(map[string]time.Duration, len(hooks))
+	unbounded := 0
+	left := remaining
+	for _, h := range hooks {
+		if h.timeout > 0 {
+			budgets[h.name] = h.timeout
+			left -= h.timeout
+		} else {
+			unbounded++
+		}
+	}
+	if unbounded == 0 {
+		return budgets
+	}
+
+	share := left / time.Duration(unbounded)
+	if share < floor {
+		share = floor
+	}
+	for _, h := range hooks {
+		if h.timeout <= 0 {
+			budgets[h.name] = share
+		}
+	}
+	return budgets
+}
@@ -0,0 +1,171 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestShutdownHonorsDependencyOrder(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.httpServers = append(g.httpServers, &http.Server{})
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			time.Sleep(5 * time.Millisecond)
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	g.OnShutdown("kafka-producer", record("kafka-producer"))
+	g.OnShutdown("db", record("db"))
+	g.OnShutdown("ingest", record("ingest"))
+
+	if err := g.After(NodeHTTP, "ingest"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.After("ingest", "kafka-producer"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.After("kafka-producer", "db"); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Shutdown()
+
+	want := []string{"ingest", "kafka-producer", "db"}
+	if len(order) != len(want) {
+		t.Fatalf("expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, order)
+		}
+	}
+}
+
+func TestAfterRejectsCycles(t *testing.T) {
+	g := New(nil)
+	if err := g.After("a", "b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.After("b", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.After("c", "a"); err == nil {
+		t.Fatal("expected cycle error")
+	}
+	if err := g.After("a", "a"); err == nil {
+		t.Fatal("expected self-dependency error")
+	}
+}
+
+func TestHookBudgetsDividedFromRemainingBudget(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 10 * time.Second
+	g.config.HookTimeoutFloor = 3 * time.Second
+
+	var gotDeadline time.Time
+	g.OnShutdown("cache", func(ctx context.Context) error {
+		gotDeadline, _ = ctx.Deadline()
+		return nil
+	})
+	g.OnShutdown("flush", func(ctx context.Context) error { return nil })
+	g.OnShutdownTimeout("metrics", 2*time.Second, func(ctx context.Context) error { return nil })
+
+	start := time.Now()
+	g.Shutdown()
+
+	report := g.Report()
+	if report == nil {
+		t.Fatal("expected shutdown report")
+	}
+	if report.HookBudgets["metrics"] != 2*time.Second {
+		t.Fatalf("explicit timeout not kept: %v", report.HookBudgets)
+	}
+	// (10s - 2s) / 2 = ~4s, above the 3s floor
+	if b := report.HookBudgets["cache"]; b < 3900*time.Millisecond || b > 4*time.Second {
+		t.Fatalf("unexpected derived budget: %v", report.HookBudgets)
+	}
+	if gotDeadline.Sub(start) > 4100*time.Millisecond {
+		t.Fatalf("hook context not bounded by its budget: %v", gotDeadline.Sub(start))
+	}
+}
+
+func TestHookBudgetsHonorFloor(t *testing.T) {
+	g := New(nil)
+	g.config.HookTimeoutFloor = time.Second
+	hooks := []hook{{name: "a"}, {name: "b"}, {name: "c"}}
+	budgets := g.hookBudgets(hooks, time.Second)
+	for name, b := range budgets {
+		if b != time.Second {
+			t.Fatalf("hook %s: expected floor 1s, got %v", name, b)
+		}
+	}
+}
+
+func TestShutdownOrderAcrossServers(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 2 * time.Second
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	public, publicAddr, err := g.ListenAndWrapHTTP("127.0.0.1:0", ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, metricsAddr, err := g.ListenAndWrapHTTP("127.0.0.1:0", ok)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetServerName(public, "public")
+	g.SetServerName(metrics, "metrics")
+
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}, Timeout: time.Second}
+	serving := func(addr string) bool {
+		resp, err := client.Get("http://" + addr)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}
+
+	var midPublic, midMetrics, lastMetrics bool
+	g.OnShutdown("probe", func(ctx context.Context) error {
+		midPublic, midMetrics = serving(publicAddr), serving(metricsAddr)
+		return nil
+	})
+	g.OnShutdown("final", func(ctx context.Context) error {
+		lastMetrics = serving(metricsAddr)
+		return nil
+	})
+	if err := g.ShutdownOrder("public", "probe", "metrics"); err != nil {
+		t.Fatal(err)
+	}
+	// The HTTP group includes the named servers
+	if err := g.After(NodeHTTP, "final"); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Shutdown()
+
+	if midPublic || !midMetrics {
+		t.Fatalf("expected public stopped and metrics serving mid-shutdown, got public=%v metrics=%v", midPublic, midMetrics)
+	}
+	if lastMetrics {
+		t.Fatal("expected metrics stopped before nodes ordered after the HTTP group")
+	}
+}
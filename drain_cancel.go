@@ -0,0 +1,122 @@
+package gracewrap
+
+import "time"
+
+// CancelDrain aborts a drain that has not passed the point of no return (the
+// end of the load balancer delay): readiness flips back to true, keep-alives
+// are re-enabled and EventDrainCancelled is emitted. A later signal or
+// Shutdown starts a new drain. It is meant for maintenance-mode toggles and
+// drains triggered by mistake from an admin endpoint.
+//
+// Every tasks, CertManager reloads and WorkerPool submissions resume. Effects
+// that already happened at drain start are not undone:
+//   - pollers stopped with StopOnDrain stay stopped
+//   - contexts from Context, Group and AttachErrGroup, and the Done channel,
+//     stay cancelled (call Context or Done again for the next drain)
+//   - drain notifications already delivered to in-flight requests, and
+//     requests cancelled because of Config.RequestBudget, stand
+//   - idle keep-alive and h2c connections closed at drain start stay closed;
+//     clients reconnect
+//
+// A drain that sent GOAWAY to gRPC clients (Config.GRPCGoAwayOnDrain), drained
+// the Envoy sidecar (Config.EnvoyAdminAddr) or was forced cannot be cancelled.
+//
+// It returns ErrNotDraining or ErrDrainCommitted when there is nothing to
+// cancel, and otherwise returns once the service is ready again.
+func (g *Graceful) CancelDrain() error {
+	g.drain.mu.Lock()
+	select {
+	case <-g.drain.ch:
+	default:
+		g.drain.mu.Unlock()
+		return ErrNotDraining
+	}
+	irreversible := g.config.EnvoyAdminAddr != "" || (g.config.GRPCGoAwayOnDrain && len(g.grpcServers) > 0)
+	if g.drain.committed || irreversible || g.cut.forced.Load() {
+		g.drain.mu.Unlock()
+		return ErrDrainCommitted
+	}
+	close(g.drain.cancel)
+	close(g.drain.moved)
+	g.drain.ch = make(chan struct{})
+	g.drain.started = time.Time{}
+	g.drain.deadline = time.Time{}
+	g.drain.ctx, g.drain.ctxCancel = nil, nil
+	g.drain.cancels++
+	g.drain.mu.Unlock()
+
+	// The shutdown in progress holds stopMu until it has restored readiness
+	g.stopMu.Lock()
+	defer g.stopMu.Unlock()
+	return nil
+}
+
+// drainCancelled returns a channel closed if the current drain is cancelled.
+func (g *Graceful) drainCancelled() <-chan struct{} {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.cancel
+}
+
+// drainCancels returns how many drains have been cancelled.
+func (g *Graceful) drainCancels() int {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.cancels
+}
+
+// commitDrain passes the point of no return, unless the drain has been
+// cancelled, in which case it returns false. The drain deadline becomes
+// deadline, the one servers are given.
+func (g *Graceful) commitDrain(deadline time.Time) bool {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	select {
+	case <-g.drain.cancel:
+		return false
+	default:
+		g.drain.committed = true
+		if !deadline.Equal(g.drain.deadline) {
+			g.drain.deadline = deadline
+			close(g.drain.moved)
+			g.drain.moved = make(chan struct{})
+		}
+		return true
+	}
+}
+
+// committedDrainDeadline returns the drain deadline once it can no longer
+// move or be cancelled.
+func (g *Graceful) committedDrainDeadline() (time.Time, bool) {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.deadline, g.drain.committed
+}
+
+// resumeAfterCancel restores the readiness recorded when the drain began.
+func (g *Graceful) resumeAfterCancel() {
+	if g.maintenance.Load() {
+		// Stay out of rotation until ExitMaintenance
+		g.logger.Printf("Drain cancelled; staying in maintenance")
+		g.emit(EventDrainCancelled, "readiness kept false (maintenance)")
+		return
+	}
+	g.drain.mu.Lock()
+	wasReady := g.drain.wasReady
+	g.drain.mu.Unlock()
+	if g.config.DisableKeepAlivesOnDrain {
+		g.setKeepAlives(true)
+	}
+	if !wasReady {
+		// e.g. SetReady(false) while caches warm up; SetReady(true) still works
+		g.logger.Printf("Drain cancelled; staying not ready as before the drain")
+		g.emit(EventDrainCancelled, "readiness kept false")
+		return
+	}
+	g.setReady(true)
+	g.logger.Printf("Drain cancelled; marked as ready again")
+	if n := g.pollerCount(); n > 0 {
+		g.logger.Printf("%d poller(s) stopped with StopOnDrain stay stopped", n)
+	}
+	g.emit(EventDrainCancelled, "readiness set to true")
+}
@@ -0,0 +1,112 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCancelDrain(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 5 * time.Second
+	g.config.HardStopTimeout = 0
+
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	if err := g.WrapHTTP(srv); err != nil {
+		t.Fatalf("wrap http: %v", err)
+	}
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+
+	if err := g.CancelDrain(); !errors.Is(err, ErrNotDraining) {
+		t.Fatalf("expected ErrNotDraining before shutdown, got %v", err)
+	}
+
+	res := make(chan error, 1)
+	go func() { res <- g.ShutdownContext(context.Background()) }()
+	for g.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+	if !g.Ready() || g.draining() {
+		t.Fatal("expected to be ready and not draining after CancelDrain")
+	}
+	if err := <-res; !errors.Is(err, ErrDrainCancelled) {
+		t.Fatalf("expected ErrDrainCancelled, got %v", err)
+	}
+	for e := range events {
+		if e.Type == EventDrainCancelled {
+			break
+		}
+	}
+
+	// Still serving, without drain headers
+	resp, err := http.Get("http://" + g.Addr("http").String())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("X-Draining") != "" {
+		t.Fatal("expected no drain header after CancelDrain")
+	}
+
+	// A later shutdown runs to completion and cannot be cancelled past the
+	// load balancer delay
+	g.config.LoadBalancerDelay = 0
+	g.Shutdown()
+	if err := g.CancelDrain(); !errors.Is(err, ErrDrainCommitted) {
+		t.Fatalf("expected ErrDrainCommitted after shutdown, got %v", err)
+	}
+}
+
+func TestCancelDrainRestoresSubsystems(t *testing.T) {
+	var logs syncBuffer
+	g := New(&Config{Logger: log.New(&logs, "", 0), LoadBalancerDelay: 5 * time.Second})
+	pool := g.NewWorkerPool(1)
+	if err := g.StopOnDrain("flags", func() {}); err != nil {
+		t.Fatal(err)
+	}
+
+	go g.Shutdown()
+	<-g.drainStarted()
+	if err := pool.Submit(func(context.Context) {}); !errors.Is(err, ErrPoolDraining) {
+		t.Fatalf("expected ErrPoolDraining while draining, got %v", err)
+	}
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+
+	ran := make(chan struct{})
+	if err := pool.Submit(func(context.Context) { close(ran) }); err != nil {
+		t.Fatalf("expected submissions to resume after CancelDrain, got %v", err)
+	}
+	<-ran
+	if !strings.Contains(logs.String(), "1 poller(s) stopped with StopOnDrain stay stopped") {
+		t.Fatalf("expected stopped pollers to be reported, got %q", logs.String())
+	}
+}
+
+func TestCancelDrainRestoresPriorReadiness(t *testing.T) {
+	g := New(&Config{LoadBalancerDelay: 5 * time.Second})
+	g.SetReady(false)
+
+	go g.Shutdown()
+	<-g.drainStarted()
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+	if g.Ready() || g.draining() {
+		t.Fatalf("expected to stay not ready and stop draining (ready %v, draining %v)", g.Ready(), g.draining())
+	}
+	g.SetReady(true)
+	if !g.Ready() {
+		t.Fatal("expected SetReady(true) to work after CancelDrain")
+	}
+}
@@ -0,0 +1,26 @@
+package gracewrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainHeadersWhileDraining(t *testing.T) {
+	g := New(nil)
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Header().Get("Connection") != "" || rr.Header().Get("X-Draining") != "" {
+		t.Fatalf("unexpected drain headers before drain: %v", rr.Header())
+	}
+
+	g.beginDrain(time.Now().Add(time.Second))
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Header().Get("Connection") != "close" || rr.Header().Get("X-Draining") != "true" {
+		t.Fatalf("expected drain headers, got %v", rr.Header())
+	}
+}
@@ -0,0 +1,83 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestDrainNotifyOutsideRequest(t *testing.T) {
+	if Draining(context.Background()) {
+		t.Fatal("expected untracked context to never report draining")
+	}
+	if DrainNotify(context.Background()) != nil {
+		t.Fatal("expected nil channel outside a tracked request")
+	}
+}
+
+func TestDrainNotifyEndsStreamingHandler(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 5 * time.Second
+
+	streaming := make(chan struct{})
+	finished := make(chan bool, 1)
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if Draining(r.Context()) {
+			t.Error("request should not be draining yet")
+		}
+		close(streaming)
+		<-DrainNotify(r.Context())
+		finished <- Draining(r.Context())
+	}))
+
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/events", nil))
+	<-streaming
+
+	start := time.Now()
+	g.Shutdown()
+	if time.Since(start) > time.Second {
+		t.Fatal("drain waited for the deadline instead of ending the stream")
+	}
+	if !<-finished {
+		t.Fatal("expected Draining to report true once notified")
+	}
+}
+
+func TestDrainChanEndsStreamAfterLoadBalancerDelay(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 100 * time.Millisecond
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 5 * time.Second
+
+	if DrainChan(context.Background()) != nil {
+		t.Fatal("expected nil channel outside a tracked request")
+	}
+
+	streaming := make(chan struct{})
+	ended := make(chan time.Time, 1)
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		close(streaming)
+		<-DrainChan(ss.Context())
+		ended <- time.Now()
+		return nil
+	}
+	go func() {
+		_ = g.grpcStreamInterceptor(nil, &fakeServerStream{}, &grpc.StreamServerInfo{FullMethod: "/svc/Watch"}, handler)
+	}()
+	<-streaming
+
+	start := time.Now()
+	g.Shutdown()
+	if time.Since(start) > time.Second {
+		t.Fatal("drain waited for the deadline instead of ending the stream")
+	}
+	if d := (<-ended).Sub(start); d < g.config.LoadBalancerDelay {
+		t.Fatalf("stream ended %v after shutdown began, before the load balancer delay", d)
+	}
+}
@@ -0,0 +1,111 @@
+package gracewrap
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// drainHistorySize is how many recent drains are kept in the history file.
+	drainHistorySize = 10
+	// drainSoftLimit is the share of DrainTimeout above which a drain counts
+	// as close to its budget.
+	drainSoftLimit = 0.8
+)
+
+// drainRecord is one drain persisted in Config.DrainHistoryFile.
+type drainRecord struct {
+	At       time.Time     `json:"at"`
+	Duration time.Duration `json:"duration"`
+	Budget   time.Duration `json:"budget"`
+}
+
+// ratio returns the share of the budget the drain used.
+func (r drainRecord) ratio() float64 {
+	if r.Budget <= 0 {
+		return 0
+	}
+	return float64(r.Duration) / float64(r.Budget)
+}
+
+// loadDrainHistory reads the recorded drains, oldest first. A missing file is
+// an empty history.
+func (g *Graceful) loadDrainHistory() ([]drainRecord, error) {
+	b, err := os.ReadFile(g.config.DrainHistoryFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var records []drainRecord
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// checkDrainHistory warns at startup when most recent drains used more than
+// 80% of their budget, and exports the mean share used.
+func (g *Graceful) checkDrainHistory() {
+	records, err := g.loadDrainHistory()
+	if err != nil {
+		g.logger.Printf("Reading drain history failed: %v", err)
+		return
+	}
+	if len(records) == 0 {
+		return
+	}
+	var sum float64
+	near := 0
+	for _, r := range records {
+		sum += r.ratio()
+		if r.ratio() > drainSoftLimit {
+			near++
+		}
+	}
+	mean := sum / float64(len(records))
+	if g.metrics != nil {
+		g.metrics.setDrainBudgetUsed(mean)
+	}
+	if near*2 >= len(records) {
+		g.logger.Printf("Warning: %d of the last %d drains used more than %.0f%% of DrainTimeout (mean %.0f%%); consider raising it before requests get dropped",
+			near, len(records), drainSoftLimit*100, mean*100)
+	}
+}
+
+// recordDrain appends a drain to the history file, keeping the most recent
+// drainHistorySize. The file is written then renamed, like cache handoffs.
+func (g *Graceful) recordDrain(d time.Duration) error {
+	records, err := g.loadDrainHistory()
+	if err != nil {
+		records = nil // start over rather than keep a corrupt file
+	}
+	records = append(records, drainRecord{At: time.Now(), Duration: d, Budget: g.config.DrainTimeout})
+	if len(records) > drainHistorySize {
+		records = records[len(records)-drainHistorySize:]
+	}
+	b, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	path := g.config.DrainHistoryFile
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,61 @@
+package gracewrap
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDrainHistoryWarnsNearBudget(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "drains.json")
+
+	newWithHistory := func(buf *bytes.Buffer) *Graceful {
+		cfg := DefaultConfig()
+		cfg.EnableMetrics = true
+		cfg.PrometheusRegistry = prometheus.NewRegistry()
+		cfg.Logger = log.New(buf, "", 0)
+		cfg.DrainHistoryFile = file
+		cfg.DrainTimeout = 100 * time.Millisecond
+		cfg.LoadBalancerDelay = 0
+		cfg.HardStopTimeout = 0
+		return New(&cfg)
+	}
+
+	// A drain that takes ~90% of its budget
+	var first bytes.Buffer
+	g := newWithHistory(&first)
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/slow")
+	time.AfterFunc(90*time.Millisecond, done)
+	g.Shutdown()
+	if r := g.Report(); r == nil || r.DrainDuration < 80*time.Millisecond {
+		t.Fatalf("expected drain duration in report, got %+v", r)
+	}
+
+	var second bytes.Buffer
+	g2 := newWithHistory(&second)
+	if !strings.Contains(second.String(), "Warning: 1 of the last 1 drains") {
+		t.Fatalf("expected soft limit warning, got %q", second.String())
+	}
+	rr := httptest.NewRecorder()
+	g2.MetricsHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), "gracewrap_drain_budget_used_ratio 0.") {
+		t.Fatalf("expected drain budget ratio metric, got %s", rr.Body.String())
+	}
+
+	// The warning stops once quick drains are the majority
+	g2.Shutdown()
+	g2 = newWithHistory(new(bytes.Buffer))
+	g2.Shutdown()
+	var third bytes.Buffer
+	newWithHistory(&third)
+	if strings.Contains(third.String(), "Warning") {
+		t.Fatalf("expected no warning once most drains are quick, got %q", third.String())
+	}
+}
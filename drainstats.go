@@ -0,0 +1,120 @@
+package gracewrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// DrainSlice summarizes one time slice of a drain, so long drains can be
+// profiled as they progress.
+type DrainSlice struct {
+	Start time.Time `json:"start"`
+	// Completed is the number of requests that finished during the slice.
+	Completed uint64 `json:"completed"`
+	// Rejected is the number of new requests rejected during the slice.
+	Rejected uint64 `json:"rejected"`
+	// Remaining is the number of requests still in flight at the slice end.
+	Remaining int64 `json:"remaining"`
+}
+
+// countRejected counts a request rejected while draining.
+func (g *Graceful) countRejected() {
+	g.inflight.mu.Lock()
+	g.inflight.rejected++
+	g.inflight.mu.Unlock()
+}
+
+// recordDrainStats records a DrainSlice every Config.DrainStatsInterval, and
+// logs the oldest requests at drain checkpoints, until the returned func is
+// called; that func records the final partial slice and returns all slices.
+func (g *Graceful) recordDrainStats() func() []DrainSlice {
+	interval := g.config.DrainStatsInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	// Slices of an earlier, cancelled drain do not belong to this one
+	g.resetDrainStats()
+
+	g.inflight.mu.Lock()
+	completed, rejected := g.inflight.completed, g.inflight.rejected
+	g.inflight.mu.Unlock()
+	sliceStart := time.Now()
+
+	record := func() {
+		g.inflight.mu.Lock()
+		s := DrainSlice{
+			Start:     sliceStart,
+			Completed: g.inflight.completed - completed,
+			Rejected:  g.inflight.rejected - rejected,
+			Remaining: g.inflight.n,
+		}
+		completed, rejected = g.inflight.completed, g.inflight.rejected
+		g.inflight.mu.Unlock()
+		sliceStart = time.Now()
+
+		g.drainStats.mu.Lock()
+		g.drainStats.slices = append(g.drainStats.slices, s)
+		g.drainStats.mu.Unlock()
+	}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				record()
+			case <-stop:
+				record()
+				return
+			}
+		}
+	}()
+	go g.logSlowRequests(stop)
+
+	return func() []DrainSlice {
+		close(stop)
+		<-done
+		return g.drainSlices()
+	}
+}
+
+// resetDrainStats forgets the drain slices recorded so far.
+func (g *Graceful) resetDrainStats() {
+	g.drainStats.mu.Lock()
+	g.drainStats.slices = nil
+	g.drainStats.mu.Unlock()
+}
+
+// drainSlices returns a copy of the drain slices recorded so far.
+func (g *Graceful) drainSlices() []DrainSlice {
+	g.drainStats.mu.Lock()
+	defer g.drainStats.mu.Unlock()
+	return append([]DrainSlice(nil), g.drainStats.slices...)
+}
+
+// StatusHandler returns an HTTP handler reporting readiness, drain state,
+// in-flight requests and the drain slices recorded so far as JSON. Mount it
+// on an admin server that is not wrapped by gracewrap.
+func (g *Graceful) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := struct {
+			Ready       bool         `json:"ready"`
+			Draining    bool         `json:"draining"`
+			Inflight    int64        `json:"inflight"`
+			DrainSlices []DrainSlice `json:"drain_slices,omitempty"`
+		}{
+			Ready:       g.Ready(),
+			Draining:    g.draining(),
+			Inflight:    g.inflightCount(),
+			DrainSlices: g.drainSlices(),
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(status)
+	})
+}
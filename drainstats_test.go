@@ -0,0 +1,99 @@
+package gracewrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDrainStatsSlices(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.RejectWhileDraining = true
+	g.config.DrainStatsInterval = 30 * time.Millisecond
+
+	started := make(chan struct{})
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		g.Shutdown()
+		close(done)
+	}()
+	<-g.drainStarted()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/new", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected rejection while draining, got %d", rec.Code)
+	}
+
+	status := httptest.NewRecorder()
+	g.StatusHandler().ServeHTTP(status, httptest.NewRequest("GET", "/status", nil))
+	var body struct {
+		Draining bool `json:"draining"`
+		Inflight int64
+	}
+	if err := json.Unmarshal(status.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if !body.Draining || body.Inflight != 1 {
+		t.Fatalf("unexpected status %s", status.Body.String())
+	}
+	<-done
+
+	slices := g.Report().DrainSlices
+	if len(slices) < 2 {
+		t.Fatalf("expected several slices, got %+v", slices)
+	}
+	var completed, rejected uint64
+	for _, s := range slices {
+		completed += s.Completed
+		rejected += s.Rejected
+	}
+	if completed != 1 || rejected != 1 {
+		t.Fatalf("expected 1 completed and 1 rejected, got %d and %d", completed, rejected)
+	}
+	if last := slices[len(slices)-1]; last.Remaining != 0 {
+		t.Fatalf("expected nothing remaining at the end, got %d", last.Remaining)
+	}
+}
+
+func TestDrainStatsResetAfterCancelledDrain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 100 * time.Millisecond
+	g.config.HardStopTimeout = 0
+	g.config.DrainStatsInterval = 10 * time.Millisecond
+
+	go g.Shutdown()
+	<-g.drainStarted()
+	time.Sleep(50 * time.Millisecond)
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+	if slices := g.drainSlices(); len(slices) != 0 {
+		t.Fatalf("expected cancelled drain slices to be discarded, got %d", len(slices))
+	}
+
+	second := time.Now()
+	g.config.LoadBalancerDelay = 0
+	g.Shutdown()
+	slices := g.Report().DrainSlices
+	if len(slices) == 0 {
+		t.Fatal("expected slices for the completed drain")
+	}
+	for _, s := range slices {
+		if s.Start.Before(second) {
+			t.Fatalf("report includes a slice from the cancelled drain: %+v", s)
+		}
+	}
+}
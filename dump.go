@@ -0,0 +1,56 @@
+package gracewrap
+
+import (
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"syscall"
+)
+
+// dumpOnSIGQUIT replaces Go's dump-and-exit SIGQUIT behavior with dumpState
+// (see Config.DumpOnSIGQUIT).
+func (g *Graceful) dumpOnSIGQUIT() {
+	g.OnSignal(syscall.SIGQUIT, func() { g.dumpState("SIGQUIT") })
+}
+
+// dumpState logs the in-flight requests and every goroutine's stack.
+func (g *Graceful) dumpState(reason string) {
+	active := g.activeRequests()
+	g.logger.Printf("State dump (%s): %d request(s) in flight, draining=%v", reason, len(active), g.draining())
+	for _, e := range active {
+		g.logger.Printf("  in flight: %s", e)
+	}
+	g.logger.Printf("Goroutine stacks:\n%s", goroutineStacks())
+}
+
+// dumpStall logs every goroutine's stack when the drain deadline passes with
+// requests still in flight, so the handler holding up shutdown can be found
+// post-mortem, and writes a goroutine profile to Config.StallProfilePath.
+func (g *Graceful) dumpStall() {
+	if g.config.DisableStallDump {
+		return
+	}
+	g.logger.Printf("Goroutine stacks at the drain deadline:\n%s", goroutineStacks())
+	path := g.config.StallProfilePath
+	if path == "" {
+		return
+	}
+	if err := writeProfile(path, func(f *os.File) error { return pprof.Lookup("goroutine").WriteTo(f, 0) }); err != nil {
+		g.logger.Printf("Goroutine profile at the drain deadline failed: %v", err)
+		return
+	}
+	g.logger.Printf("Wrote goroutine profile to %s", path)
+	g.updateReport(func(r *ShutdownReport) { r.Profiles = append(r.Profiles, path) })
+}
+
+// goroutineStacks returns the stack traces of all goroutines.
+func goroutineStacks() []byte {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
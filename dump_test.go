@@ -0,0 +1,92 @@
+package gracewrap
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer safe for a logger written from other goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestDumpOnSIGQUIT(t *testing.T) {
+	var logs syncBuffer
+	cfg := DefaultConfig()
+	cfg.DumpOnSIGQUIT = true
+	cfg.Logger = log.New(&logs, "", 0)
+	g := New(&cfg)
+
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/stuck")
+	defer done()
+
+	p, _ := os.FindProcess(os.Getpid())
+	_ = p.Signal(syscall.SIGQUIT)
+
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for !strings.Contains(logs.String(), "Goroutine stacks:") {
+		if time.Now().After(deadline) {
+			t.Skip("signal delivery not available in this environment")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	out := logs.String()
+	for _, want := range []string{"1 request(s) in flight", "in flight: http GET /stuck", "goroutine "} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in dump, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestStallDumpAtDrainDeadline(t *testing.T) {
+	var logs syncBuffer
+	cfg := DefaultConfig()
+	cfg.Logger = log.New(&logs, "", 0)
+	cfg.LoadBalancerDelay = 0
+	cfg.HardStopTimeout = 0
+	cfg.DrainTimeout = 50 * time.Millisecond
+	cfg.StallProfilePath = filepath.Join(t.TempDir(), "stall.pprof")
+	g := New(&cfg)
+
+	release := make(chan struct{})
+	defer close(release)
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/stuck")
+	go func() {
+		<-release
+		done()
+	}()
+
+	g.Shutdown()
+
+	if !strings.Contains(logs.String(), "Goroutine stacks at the drain deadline") ||
+		!strings.Contains(logs.String(), "TestStallDumpAtDrainDeadline.func1") {
+		t.Fatalf("expected the stuck goroutine in the stall dump, got %q", logs.String())
+	}
+	if info, err := os.Stat(cfg.StallProfilePath); err != nil || info.Size() == 0 {
+		t.Fatalf("expected goroutine profile at %s: %v", cfg.StallProfilePath, err)
+	}
+	if r := g.Report(); len(r.Profiles) != 1 || r.Profiles[0] != cfg.StallProfilePath {
+		t.Fatalf("expected stall profile in report, got %v", r.Profiles)
+	}
+}
@@ -18,12 +18,10 @@ func TestWaitForInflightTimeout(t *testing.T) {
 	g.incInflight()
 	g.incInflight()
 
-	// Test with past deadline (should return false immediately)
+	// Test with past deadline (should give up immediately)
 	pastDeadline := time.Now().Add(-1 * time.Second)
-	ok := g.waitForInflight(pastDeadline)
-
-	if ok {
-		t.Error("Expected timeout with past deadline")
+	if abandoned := g.waitForInflight(pastDeadline); abandoned != 2 {
+		t.Errorf("Expected 2 abandoned requests with past deadline, got %d", abandoned)
 	}
 
 	// Clean up
@@ -31,6 +29,30 @@ func TestWaitForInflightTimeout(t *testing.T) {
 	g.decInflight()
 }
 
+// TestWaitForInflightWakesAtDeadline tests that a stuck request does not
+// hold the wait past its deadline
+func TestWaitForInflightWakesAtDeadline(t *testing.T) {
+	g := New(nil)
+	g.incInflight()
+
+	start := time.Now()
+	if abandoned := g.waitForInflight(start.Add(50 * time.Millisecond)); abandoned != 1 {
+		t.Fatalf("Expected 1 abandoned request, got %d", abandoned)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Fatalf("Expected to give up at the deadline, waited %v", waited)
+	}
+
+	// A request finishing mid-wait ends it early
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		g.decInflight()
+	}()
+	if abandoned := g.waitForInflight(time.Now().Add(5 * time.Second)); abandoned != 0 {
+		t.Fatalf("Expected no abandoned requests, got %d", abandoned)
+	}
+}
+
 // TestTrackedStreamMethods tests gRPC stream wrapper methods
 func TestTrackedStreamMethods(t *testing.T) {
 	stream := &testServerStream{}
@@ -0,0 +1,67 @@
+package gracewrap
+
+import (
+	"context"
+	"fmt"
+)
+
+// StopEmbedded registers an embedded server or datastore to be closed once
+// the API servers (NodeHTTP, NodeGRPC, NodeHTTP3) have drained, so no request
+// can touch it mid-close. Order a final flush after it with
+// After(name, "flush"). c may be any of:
+//
+//	Shutdown() + WaitForShutdown()    // embedded NATS server
+//	Sync() error + Close() error      // BadgerDB, bbolt (synced, then closed)
+//	Close()                           // embedded etcd (embed.Etcd)
+//
+// or any shape accepted by StopOnDrain. Closing runs under the hook budget;
+// if it overruns, shutdown moves on while the close completes in background.
+func (g *Graceful) StopEmbedded(name string, c interface{}) error {
+	stop, err := embeddedStopFunc(c)
+	if err != nil {
+		return fmt.Errorf("gracewrap: StopEmbedded(%q): %w", name, err)
+	}
+	for _, api := range []string{NodeHTTP, NodeGRPC, NodeHTTP3} {
+		if err := g.After(api, name); err != nil {
+			return err
+		}
+	}
+	g.OnShutdown(name, func(ctx context.Context) error {
+		errc := make(chan error, 1)
+		go func() { errc <- stop(ctx) }()
+		select {
+		case err := <-errc:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	return nil
+}
+
+// embeddedStopFunc adapts c to a stop function.
+func embeddedStopFunc(c interface{}) (func(ctx context.Context) error, error) {
+	switch v := c.(type) {
+	case interface {
+		Shutdown()
+		WaitForShutdown()
+	}:
+		return func(context.Context) error {
+			v.Shutdown()
+			v.WaitForShutdown()
+			return nil
+		}, nil
+	case interface {
+		Sync() error
+		Close() error
+	}:
+		return func(context.Context) error {
+			if err := v.Sync(); err != nil {
+				_ = v.Close()
+				return fmt.Errorf("sync: %w", err)
+			}
+			return v.Close()
+		}, nil
+	}
+	return pollerStopFunc(c)
+}
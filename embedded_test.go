@@ -0,0 +1,78 @@
+package gracewrap
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+type closeRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *closeRecorder) record(s string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, s)
+}
+
+type fakeNATS struct{ r *closeRecorder }
+
+func (n fakeNATS) Shutdown()        { n.r.record("nats shutdown") }
+func (n fakeNATS) WaitForShutdown() { n.r.record("nats stopped") }
+
+type fakeBadger struct{ r *closeRecorder }
+
+func (b fakeBadger) Sync() error  { b.r.record("badger sync"); return nil }
+func (b fakeBadger) Close() error { b.r.record("badger close"); return nil }
+
+func TestStopEmbeddedAfterAPIDrain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	rec := &closeRecorder{}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	started := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		rec.record("request done")
+	})}
+	if err := g.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := g.StopEmbedded("nats", fakeNATS{rec}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.StopEmbedded("badger", fakeBadger{rec}); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.StopEmbedded("bad", 42); err == nil {
+		t.Fatal("expected an error for an unsupported type")
+	}
+
+	go func() { _, _ = http.Get("http://" + ln.Addr().String()) }()
+	<-started
+	g.Shutdown()
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if len(rec.calls) != 5 || rec.calls[0] != "request done" {
+		t.Fatalf("expected embedded components to close after HTTP drained, got %v", rec.calls)
+	}
+	pos := map[string]int{}
+	for i, c := range rec.calls {
+		pos[c] = i
+	}
+	if pos["nats shutdown"] > pos["nats stopped"] || pos["badger sync"] > pos["badger close"] {
+		t.Fatalf("unexpected close sequence %v", rec.calls)
+	}
+}
@@ -0,0 +1,53 @@
+package gracewrap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// envoyAdminTimeout bounds each call to the Envoy admin endpoint.
+const envoyAdminTimeout = 2 * time.Second
+
+// drainEnvoy tells the Envoy sidecar at Config.EnvoyAdminAddr to fail its
+// health checks and gracefully drain its listeners, so the mesh stops
+// routing to this pod before the load balancer delay starts. Failures are
+// logged and do not stop the shutdown.
+func (g *Graceful) drainEnvoy(ctx context.Context) {
+	if g.config.EnvoyAdminAddr == "" {
+		return
+	}
+	base := g.config.EnvoyAdminAddr
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	base = strings.TrimSuffix(base, "/")
+	for _, path := range []string{"/healthcheck/fail", "/drain_listeners?graceful"} {
+		if err := envoyAdminPost(ctx, base+path); err != nil {
+			g.logger.Printf("Envoy admin %s failed: %v", path, err)
+			return
+		}
+	}
+	g.logger.Printf("Envoy sidecar is failing health checks and draining listeners")
+}
+
+// envoyAdminPost sends an empty POST to an Envoy admin URL.
+func envoyAdminPost(ctx context.Context, url string) error {
+	ctx, cancel := context.WithTimeout(ctx, envoyAdminTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
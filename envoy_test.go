@@ -0,0 +1,51 @@
+package gracewrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestDrainEnvoy(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls = append(calls, r.Method+" "+r.URL.RequestURI())
+		mu.Unlock()
+	}))
+	defer admin.Close()
+
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.EnvoyAdminAddr = strings.TrimPrefix(admin.URL, "http://")
+	g.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"POST /healthcheck/fail", "POST /drain_listeners?graceful"}
+	if strings.Join(calls, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, calls)
+	}
+}
+
+func TestDrainEnvoyFailureDoesNotBlockShutdown(t *testing.T) {
+	admin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer admin.Close()
+
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.EnvoyAdminAddr = admin.URL
+	g.Shutdown()
+	select {
+	case <-g.done:
+	default:
+		t.Fatal("shutdown did not complete")
+	}
+}
@@ -0,0 +1,34 @@
+package gracewrap
+
+import (
+	"context"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// AttachErrGroup ties an existing errgroup to the Graceful lifecycle.
+// ctx must be the context returned by errgroup.WithContext for group.
+// The returned context should be handed to the group's workers: it is
+// cancelled when shutdown starts or when the group fails. The first error
+// returned by a worker triggers graceful shutdown through Fail.
+func (g *Graceful) AttachErrGroup(group *errgroup.Group, ctx context.Context) context.Context {
+	workerCtx, cancel := context.WithCancel(ctx)
+
+	go func() {
+		select {
+		case <-g.drainStarted():
+		case <-workerCtx.Done():
+		}
+		cancel()
+	}()
+
+	go func() {
+		// The group context is cancelled on the first worker error
+		<-ctx.Done()
+		if err := group.Wait(); err != nil {
+			g.Fail(err)
+		}
+	}()
+
+	return workerCtx
+}
@@ -0,0 +1,59 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+func TestAttachErrGroupErrorTriggersShutdown(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	group, groupCtx := errgroup.WithContext(context.Background())
+	ctx := g.AttachErrGroup(group, groupCtx)
+
+	boom := errors.New("consumer crashed")
+	group.Go(func() error { return boom })
+	group.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- g.Wait(context.Background()) }()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, boom) {
+			t.Fatalf("expected group error from Wait, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("group error did not trigger shutdown")
+	}
+	if g.Ready() {
+		t.Fatal("expected not ready after group failure")
+	}
+}
+
+func TestAttachErrGroupCancelledAtShutdown(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	group, groupCtx := errgroup.WithContext(context.Background())
+	ctx := g.AttachErrGroup(group, groupCtx)
+	group.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+
+	g.Shutdown()
+	if err := group.Wait(); err != nil {
+		t.Fatalf("unexpected group error: %v", err)
+	}
+}
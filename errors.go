@@ -0,0 +1,33 @@
+package gracewrap
+
+import "errors"
+
+// ErrDrainDeadlineExceeded is returned when work did not finish before the
+// drain deadline, including by Wait and ShutdownContext when requests were
+// still in flight at the deadline.
+var ErrDrainDeadlineExceeded = errors.New("gracewrap: drain deadline exceeded")
+
+// ErrForcedStop is returned by Wait and ShutdownContext when the shutdown was
+// forced (see ForceShutdown) instead of draining.
+var ErrForcedStop = errors.New("gracewrap: shutdown forced")
+
+// ErrHookFailed is returned by Wait and ShutdownContext, wrapped with the
+// hook's name and error, when a shutdown hook returned an error.
+var ErrHookFailed = errors.New("gracewrap: shutdown hook failed")
+
+// ErrDraining is returned by TrackRequest for requests rejected while draining.
+var ErrDraining = errors.New("gracewrap: draining")
+
+// ErrOverloaded is returned by TrackRequest for requests shed by MaxInflight.
+var ErrOverloaded = errors.New("gracewrap: too many requests in flight")
+
+// ErrNotDraining is returned by CancelDrain when no drain is in progress.
+var ErrNotDraining = errors.New("gracewrap: not draining")
+
+// ErrDrainCommitted is returned by CancelDrain once the drain is past the
+// point of no return.
+var ErrDrainCommitted = errors.New("gracewrap: drain can no longer be cancelled")
+
+// ErrDrainCancelled is returned by ShutdownContext when the drain it started
+// was cancelled with CancelDrain.
+var ErrDrainCancelled = errors.New("gracewrap: drain cancelled")
@@ -0,0 +1,73 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownErrors(t *testing.T) {
+	newShutdown := func() *Graceful {
+		g := newTestGraceful(t)
+		g.config.LoadBalancerDelay = 0
+		g.config.HardStopTimeout = 0
+		g.config.DrainTimeout = 50 * time.Millisecond
+		return g
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		if err := newShutdown().ShutdownWithTimeout(time.Second); err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	})
+
+	t.Run("hook failed", func(t *testing.T) {
+		g := newShutdown()
+		g.OnShutdown("flush", func(ctx context.Context) error { return errors.New("disk full") })
+		err := g.ShutdownWithTimeout(time.Second)
+		if !errors.Is(err, ErrHookFailed) || !strings.Contains(err.Error(), `"flush": disk full`) {
+			t.Fatalf("expected ErrHookFailed for flush, got %v", err)
+		}
+		if errors.Is(err, ErrForcedStop) || errors.Is(err, ErrDrainDeadlineExceeded) {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if hooks := g.Report().Hooks; len(hooks) != 1 || hooks[0].Error != "disk full" {
+			t.Fatalf("expected failed hook in report, got %+v", hooks)
+		}
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		g := newShutdown()
+		_, _ = g.trackRequest(context.Background(), "http", "GET", "/stuck")
+		g.OnShutdown("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		})
+		if err := g.ShutdownWithTimeout(time.Second); !errors.Is(err, ErrDrainDeadlineExceeded) {
+			t.Fatalf("expected ErrDrainDeadlineExceeded, got %v", err)
+		}
+	})
+
+	t.Run("forced", func(t *testing.T) {
+		g := newShutdown()
+		g.ForceShutdown()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := g.Wait(ctx); !errors.Is(err, ErrForcedStop) {
+			t.Fatalf("expected ErrForcedStop, got %v", err)
+		}
+	})
+
+	t.Run("fail cause kept", func(t *testing.T) {
+		g := newShutdown()
+		cause := errors.New("listener lost")
+		g.OnShutdown("flush", func(ctx context.Context) error { return errors.New("disk full") })
+		g.Fail(cause)
+		err := g.Wait(context.Background())
+		if !errors.Is(err, cause) || !errors.Is(err, ErrHookFailed) {
+			t.Fatalf("expected cause and ErrHookFailed, got %v", err)
+		}
+	})
+}
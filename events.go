@@ -0,0 +1,144 @@
+package gracewrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Lifecycle event types.
+const (
+	EventDrainStarted      = "drain_started"
+	EventLoadBalancerDelay = "load_balancer_delay"
+	EventServersStopping   = "servers_stopping"
+	EventInflightWait      = "inflight_wait"
+	EventDrainTimeout      = "drain_timeout"
+	EventHardStop          = "hard_stop"
+	EventShutdownCompleted = "shutdown_completed"
+	EventClockJump         = "clock_jump"
+	EventDrainCancelled    = "drain_cancelled"
+)
+
+// EventCode is a stable, machine-readable identifier for a lifecycle event.
+// Codes never change meaning or get reused, so fleet-wide log alerting can
+// key on them instead of message text.
+type EventCode string
+
+// Stable lifecycle event codes.
+const (
+	CodeDrainStarted      EventCode = "GW001"
+	CodeDrainTimeout      EventCode = "GW002"
+	CodeLoadBalancerDelay EventCode = "GW003"
+	CodeServersStopping   EventCode = "GW004"
+	CodeInflightWait      EventCode = "GW005"
+	CodeHardStop          EventCode = "GW006"
+	CodeShutdownCompleted EventCode = "GW007"
+	CodeClockJump         EventCode = "GW008"
+	CodeDrainCancelled    EventCode = "GW009"
+)
+
+// eventCodes maps event types to their stable codes.
+var eventCodes = map[string]EventCode{
+	EventDrainStarted:      CodeDrainStarted,
+	EventDrainTimeout:      CodeDrainTimeout,
+	EventLoadBalancerDelay: CodeLoadBalancerDelay,
+	EventServersStopping:   CodeServersStopping,
+	EventInflightWait:      CodeInflightWait,
+	EventHardStop:          CodeHardStop,
+	EventShutdownCompleted: CodeShutdownCompleted,
+	EventClockJump:         CodeClockJump,
+	EventDrainCancelled:    CodeDrainCancelled,
+}
+
+// Event is a lifecycle event emitted during shutdown.
+type Event struct {
+	Code    EventCode `json:"code"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message,omitempty"`
+}
+
+// Subscribe returns a channel of lifecycle events, starting with the events
+// already emitted, and a func to unsubscribe. Slow subscribers miss events
+// rather than blocking shutdown.
+func (g *Graceful) Subscribe() (<-chan Event, func()) {
+	g.events.mu.Lock()
+	defer g.events.mu.Unlock()
+
+	ch := make(chan Event, len(g.events.history)+64)
+	for _, e := range g.events.history {
+		ch <- e
+	}
+	if g.events.subs == nil {
+		g.events.subs = make(map[chan Event]struct{})
+	}
+	g.events.subs[ch] = struct{}{}
+
+	return ch, func() {
+		g.events.mu.Lock()
+		defer g.events.mu.Unlock()
+		if _, ok := g.events.subs[ch]; ok {
+			delete(g.events.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// emit records a lifecycle event and delivers it to subscribers.
+func (g *Graceful) emit(typ, format string, args ...interface{}) {
+	e := Event{Code: eventCodes[typ], Type: typ, Time: time.Now(), Message: fmt.Sprintf(format, args...)}
+	if g.config.StructuredLogs {
+		line, _ := json.Marshal(e)
+		g.logger.Print(string(line))
+	}
+
+	g.events.mu.Lock()
+	defer g.events.mu.Unlock()
+	g.events.history = append(g.events.history, e)
+	for ch := range g.events.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// EventsHandler returns an HTTP handler streaming lifecycle events as
+// Server-Sent Events, e.g. `curl -N http://pod:9091/events`. The stream ends
+// after the shutdown_completed event. Mount it on an admin server that is not
+// wrapped by gracewrap, since wrapped servers stop during drain.
+func (g *Graceful) EventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		events, unsubscribe := g.Subscribe()
+		defer unsubscribe()
+
+		for {
+			select {
+			case e, ok := <-events:
+				if !ok {
+					return
+				}
+				data, _ := json.Marshal(e)
+				fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data)
+				flusher.Flush()
+				if e.Type == EventShutdownCompleted {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
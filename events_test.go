@@ -0,0 +1,71 @@
+package gracewrap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventsHandlerStreamsShutdown(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 10 * time.Millisecond
+	g.config.HardStopTimeout = 0
+
+	admin := httptest.NewServer(g.EventsHandler())
+	defer admin.Close()
+
+	resp, err := http.Get(admin.URL)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	go g.Shutdown()
+
+	var types []string
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "event: ") {
+			types = append(types, strings.TrimPrefix(line, "event: "))
+		}
+	}
+
+	want := []string{EventDrainStarted, EventLoadBalancerDelay, EventServersStopping, EventInflightWait, EventShutdownCompleted}
+	if strings.Join(types, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+}
+
+func TestSubscribeReplaysHistory(t *testing.T) {
+	g := New(nil)
+	g.emit(EventDrainStarted, "x")
+
+	events, unsubscribe := g.Subscribe()
+	defer unsubscribe()
+	if e := <-events; e.Type != EventDrainStarted {
+		t.Fatalf("expected replayed event, got %+v", e)
+	}
+}
+
+func TestStructuredLogsCarryEventCodes(t *testing.T) {
+	var buf bytes.Buffer
+	g := New(&Config{Logger: log.New(&buf, "", 0), StructuredLogs: true})
+	g.emit(EventDrainTimeout, "%d request(s) still in flight", 2)
+
+	var e Event
+	if err := json.Unmarshal(bytes.TrimSpace(buf.Bytes()), &e); err != nil {
+		t.Fatalf("expected a JSON log line, got %q: %v", buf.String(), err)
+	}
+	if e.Code != CodeDrainTimeout || e.Type != EventDrainTimeout {
+		t.Fatalf("unexpected event %+v", e)
+	}
+}
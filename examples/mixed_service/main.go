@@ -30,7 +30,8 @@ func main() {
 	httpMux.Handle("/health/live", graceful.LivenessHandler())
 	httpMux.Handle("/metrics", graceful.MetricsHandler())
 
-	// Wrap your existing HTTP server
+	// Wrap your existing HTTP server, named for shutdown logs and metrics
+	graceful.SetServerName(httpServer, "public-api")
 	if err := graceful.WrapHTTP(httpServer); err != nil {
 		log.Fatal(err)
 	}
@@ -41,11 +42,16 @@ func main() {
 	// grpcServer.RegisterService(...)
 
 	// Start gRPC server
-	_, _, err := graceful.ServeGRPC(":9090")
+	_, _, err := graceful.ServeGRPC(":9090", gracewrap.WithServerName("internal"))
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Stop public traffic before the internal service it calls
+	if err := graceful.ShutdownOrder("public-api", "internal"); err != nil {
+		log.Fatal(err)
+	}
+
 	log.Println("Mixed service starting:")
 	log.Println("  HTTP: http://localhost:8080/api/status")
 	log.Println("  gRPC: localhost:9090")
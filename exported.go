@@ -0,0 +1,62 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// HTTPMiddleware returns gracewrap's tracking middleware for servers started
+// outside WrapHTTP (framework-owned listeners, serverless adapters), so their
+// requests count as in flight, honor drain rejection and delay shutdown.
+// Handlers it returns are not wrapped again by WrapHTTP.
+func (g *Graceful) HTTPMiddleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return &trackedHandler{Handler: g.httpMiddleware(next)}
+	}
+}
+
+// UnaryInterceptor returns gracewrap's tracking interceptor for unary RPCs on
+// servers not created with NewGRPCServer.
+func (g *Graceful) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return g.grpcUnaryInterceptor
+}
+
+// StreamInterceptor returns gracewrap's tracking interceptor for streaming
+// RPCs on servers not created with NewGRPCServer.
+func (g *Graceful) StreamInterceptor() grpc.StreamServerInterceptor {
+	return g.grpcStreamInterceptor
+}
+
+// TrackRequest counts a request as in flight until done is called, for
+// frameworks whose requests pass through hooks rather than a handler or an
+// interceptor (e.g. Twirp's ServerHooks). kind labels it like "http" and
+// "grpc" do in RequestInfo and metrics. Route policies apply to path.
+//
+// It returns ErrDraining or ErrOverloaded instead when the request should be
+// rejected (see RejectWhileDraining and MaxInflight); done is then nil.
+func (g *Graceful) TrackRequest(ctx context.Context, kind, method, path string) (_ context.Context, done func(), err error) {
+	policy := g.routePolicy(path)
+	if policy != nil && policy.Untracked {
+		return ctx, func() {}, nil
+	}
+
+	if g.rejecting() {
+		g.countRejected()
+		if g.metrics != nil {
+			g.metrics.incRejected(kind)
+		}
+		return ctx, nil, ErrDraining
+	}
+
+	if g.overloaded() {
+		if g.metrics != nil {
+			g.metrics.incShed(kind)
+		}
+		return ctx, nil, ErrOverloaded
+	}
+
+	ctx, done = g.trackRoute(ctx, policy, kind, method, path)
+	return ctx, done, nil
+}
@@ -0,0 +1,65 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestExportedMiddlewareAndInterceptors(t *testing.T) {
+	g := New(nil)
+
+	var seen []int64
+	record := func() { seen = append(seen, g.inflightCount()) }
+
+	h := g.HTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { record() }))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if _, ok := h.(*trackedHandler); !ok {
+		t.Fatal("expected handler to be marked as tracked")
+	}
+
+	_, _ = g.UnaryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/M"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { record(); return nil, nil })
+	_ = g.StreamInterceptor()(nil, &fakeServerStream{}, &grpc.StreamServerInfo{FullMethod: "/svc/S"},
+		func(srv interface{}, ss grpc.ServerStream) error { record(); return nil })
+
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatalf("call %d: expected to be tracked as in flight, got %d", i, n)
+		}
+	}
+	if len(seen) != 3 || g.requestsSeen() != 3 {
+		t.Fatalf("expected 3 tracked calls, got %d", g.requestsSeen())
+	}
+}
+
+func TestTrackRequest(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.RejectWhileDraining = true
+	g.config.MaxInflight = 1
+
+	_, done, err := g.TrackRequest(context.Background(), "twirp", "twirp", "pkg.Svc")
+	if err != nil {
+		t.Fatalf("track: %v", err)
+	}
+	if g.inflightCount() != 1 {
+		t.Fatalf("expected 1 request in flight, got %d", g.inflightCount())
+	}
+	if _, _, err := g.TrackRequest(context.Background(), "twirp", "twirp", "pkg.Svc"); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("expected ErrOverloaded, got %v", err)
+	}
+	done()
+	if g.inflightCount() != 0 {
+		t.Fatalf("expected no request in flight, got %d", g.inflightCount())
+	}
+
+	g.beginDrain(time.Now().Add(time.Second))
+	if _, _, err := g.TrackRequest(context.Background(), "twirp", "twirp", "pkg.Svc"); !errors.Is(err, ErrDraining) {
+		t.Fatalf("expected ErrDraining, got %v", err)
+	}
+}
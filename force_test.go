@@ -0,0 +1,65 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestForceShutdown(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 5 * time.Second
+	g.config.DrainTimeout = 10 * time.Second
+	g.config.HardStopTimeout = 5 * time.Second
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	if err := g.WrapHTTP(srv); err != nil {
+		t.Fatalf("wrap http: %v", err)
+	}
+
+	var regular, critical atomic.Bool
+	g.OnShutdown("regular", func(context.Context) error {
+		regular.Store(true)
+		return nil
+	})
+	g.OnShutdownCritical("wal", time.Second, func(ctx context.Context) error {
+		if ctx.Err() != nil {
+			t.Errorf("critical hook got a cancelled context: %v", ctx.Err())
+		}
+		critical.Store(true)
+		return nil
+	})
+
+	reqErr := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + g.Addr("http").String())
+		if err == nil {
+			resp.Body.Close()
+		}
+		reqErr <- err
+	}()
+	<-started
+
+	start := time.Now()
+	g.ForceShutdown()
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("forced shutdown took %v", d)
+	}
+	if err := <-reqErr; err == nil {
+		t.Fatal("expected the in-flight request to be cut off")
+	}
+	if !critical.Load() {
+		t.Fatal("expected the critical hook to run")
+	}
+	if regular.Load() {
+		t.Fatal("expected regular hooks to be skipped")
+	}
+}
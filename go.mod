@@ -4,8 +4,12 @@ go 1.21
 
 require (
 	github.com/prometheus/client_golang v1.17.0
-	golang.org/x/net v0.23.0 // indirect; Security fix for GO-2024-2687
+	github.com/prometheus/common v0.44.0
+	golang.org/x/net v0.23.0 // Security fix for GO-2024-2687
+	golang.org/x/sync v0.6.0
+	golang.org/x/sys v0.18.0
 	google.golang.org/grpc v1.59.0
+	google.golang.org/protobuf v1.31.0
 )
 
 require (
@@ -14,10 +18,7 @@ require (
 	github.com/golang/protobuf v1.5.3 // indirect
 	github.com/matttproud/golang_protobuf_extensions v1.0.4 // indirect
 	github.com/prometheus/client_model v0.4.1-0.20230718164431-9a2bf3000d16 // indirect
-	github.com/prometheus/common v0.44.0 // indirect
 	github.com/prometheus/procfs v0.11.1 // indirect
-	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	google.golang.org/genproto/googleapis/rpc v0.0.0-20230822172742-b8732ec3820d // indirect
-	google.golang.org/protobuf v1.31.0 // indirect
 )
@@ -2,17 +2,19 @@ package gracewrap
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"log"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
 	"sync"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 // Graceful wraps your existing services with graceful shutdown capabilities.
@@ -23,25 +25,216 @@ type Graceful struct {
 	logger *log.Logger
 
 	// State management
-	readyMu sync.RWMutex
-	ready   bool
-	started time.Time
+	readyMu     sync.RWMutex
+	ready       bool
+	maintenance atomic.Bool // see EnterMaintenance
+	started     time.Time
 
 	// In-flight request tracking
 	inflight struct {
+		mu     sync.Mutex
+		n      int64
+		idle   chan struct{} // closed while n is zero
+		nextID uint64        // also the number of requests seen since start
+		active map[uint64]*requestEntry
+
+		completed uint64 // requests finished since start
+		rejected  uint64 // requests rejected while draining
+	}
+
+	// Per-slice drain statistics (see DrainSlice)
+	drainStats struct {
+		mu     sync.Mutex
+		slices []DrainSlice
+	}
+
+	// Background tasks still ticking (see Every)
+	tasks struct {
+		mu   sync.Mutex
+		n    int
+		idle chan struct{} // closed while n is zero
+	}
+
+	// Shutdown hooks and their ordering (see OnShutdown and After)
+	order struct {
+		mu    sync.Mutex
+		hooks []hook
+		deps  map[string][]string
+	}
+
+	// Lifecycle events (see Subscribe)
+	events struct {
+		mu      sync.Mutex
+		history []Event
+		subs    map[chan Event]struct{}
+	}
+
+	// Report of the most recent shutdown (see Report)
+	report struct {
+		mu sync.Mutex
+		r  *ShutdownReport
+	}
+
+	// Worker pools drained at shutdown (see NewWorkerPool)
+	pools struct {
+		mu   sync.Mutex
+		list []*WorkerPool
+	}
+
+	// Pollers stopped at drain start (see StopOnDrain)
+	pollers struct {
+		mu   sync.Mutex
+		list []poller
+	}
+
+	// Started servers and their bound addresses (see Servers)
+	servers struct {
+		mu    sync.Mutex
+		list  []ServerInfo
+		names map[interface{}]string // see SetServerName
+	}
+
+	// Caches persisted to handoff files (see RegisterCache)
+	caches struct {
+		mu   sync.Mutex
+		list []cacheEntry
+	}
+
+	// WebSocket connections closed at drain (see TrackWebsocket)
+	websockets struct {
 		mu sync.Mutex
-		n  int64
-		cv *sync.Cond
+		m  map[*trackedWebsocket]struct{}
+	}
+
+	// Sessions handed off at drain (see RegisterSession)
+	sessions struct {
+		mu   sync.Mutex
+		next uint64
+		m    map[uint64]Session
+	}
+
+	// grpc.health.v1 services kept in step with readiness (see WithHealthService)
+	health struct {
+		mu   sync.Mutex
+		list []grpcHealth
+	}
+
+	// Cancel funcs of each HTTP server's base contexts (see setBaseContext)
+	baseContexts struct {
+		mu      sync.Mutex
+		cancels map[*http.Server][]context.CancelFunc
+	}
+
+	// Per-server rejection policies (see SetRejectionPolicy and
+	// SetGRPCRejectionPolicy)
+	rejection struct {
+		mu       sync.Mutex
+		byServer map[*http.Server]RejectionPolicy
+		byGRPC   map[*grpc.Server]RejectionPolicy
+	}
+
+	// Per-server gRPC stop policies (see SetGRPCStopPolicy)
+	grpcStop struct {
+		mu       sync.Mutex
+		byServer map[*grpc.Server]GRPCStopPolicy
+	}
+
+	// Bounds concurrent forwards of rejected requests (see DrainRetryConfig)
+	retryQueue chan struct{}
+
+	// HTTP connection states (see trackConnState)
+	conns connTracker
+
+	// Drain triggers for h2c connections (see EnableH2C)
+	h2 struct {
+		mu       sync.Mutex
+		triggers []*http.Server
 	}
 
 	// Tracked servers
-	httpServers []*http.Server
-	grpcServers []*grpc.Server
-	listeners   []net.Listener
+	httpServers  []*http.Server
+	grpcServers  []*grpc.Server
+	http3Servers []HTTP3Server
+	listeners    []net.Listener
+
+	// Drain state
+	drain struct {
+		mu        sync.Mutex
+		ch        chan struct{}
+		stopping  chan struct{} // closed when servers begin stopping
+		started   time.Time
+		deadline  time.Time
+		cancel    chan struct{} // closed by CancelDrain
+		moved     chan struct{} // closed when the deadline moves or the drain is cancelled
+		committed bool          // past the point where CancelDrain works
+		cancels   int           // drains cancelled so far
+		wasReady  bool          // readiness when drain began, restored by CancelDrain
+
+		// Context shared by Context callers, cancelled when drain begins
+		ctx       context.Context
+		ctxCancel context.CancelFunc
+	}
+
+	// Shutdown requested by the application (see Fail)
+	stop struct {
+		once sync.Once
+		ch   chan struct{}
+		err  error
+	}
 
-	// Shutdown control
-	stopOnce sync.Once
-	metrics  *metrics
+	// Readiness conditions (see AddReadinessCheck)
+	checks struct {
+		mu      sync.Mutex
+		list    []readinessCheck
+		running chan struct{} // closed when the run in progress finishes
+		last    []error       // failures of the last finished run
+		ran     bool          // a run has finished
+		loop    sync.Once     // starts the background runs
+	}
+
+	// What every Wait call returns once shutdown has completed
+	result struct {
+		once sync.Once
+		err  error
+	}
+
+	// Windows Service Control Manager stop requests (see platformSources)
+	service struct {
+		once  sync.Once
+		stops chan string
+	}
+
+	// Signal actions registered with OnSignal
+	signals struct {
+		mu       sync.Mutex
+		ch       chan os.Signal
+		handlers map[os.Signal][]func()
+	}
+
+	// Closed when a ShutdownContext deadline or ForceShutdown cuts the
+	// shutdown short
+	cut struct {
+		once   sync.Once
+		ch     chan struct{}
+		forced atomic.Bool // see ForceShutdown
+	}
+
+	// Liveness probe servers kept up after drain (see Config.LivenessPath)
+	probes struct {
+		mu   sync.Mutex
+		list []*http.Server
+	}
+
+	// Closed once shutdown has completed
+	done chan struct{}
+
+	// Servers that stopped serving on their own (see Errs)
+	errs chan error
+
+	// Shutdown control: one shutdown runs at a time, and a cancelled drain
+	// (see CancelDrain) lets a later one start
+	stopMu  sync.Mutex
+	metrics *metrics
 }
 
 // New creates a new Graceful wrapper with the given configuration.
@@ -70,119 +263,323 @@ func New(config *Config) *Graceful {
 		g.metrics = newMetrics(g.config.PrometheusRegistry)
 	}
 
-	// Initialize condition variable
-	g.inflight.cv = sync.NewCond(&g.inflight.mu)
+	g.inflight.idle = make(chan struct{})
+	close(g.inflight.idle)
+	g.tasks.idle = make(chan struct{})
+	close(g.tasks.idle)
+	g.drain.ch = make(chan struct{})
+	g.drain.stopping = make(chan struct{})
+	g.stop.ch = make(chan struct{})
+	g.cut.ch = make(chan struct{})
+	g.done = make(chan struct{})
+	g.errs = make(chan error, 16)
+	g.retryQueue = newRetryQueue(g.config.DrainRetry)
+
+	g.fitGracePeriod()
+	if g.config.DumpOnSIGQUIT {
+		g.dumpOnSIGQUIT()
+	}
+	if g.config.DrainHistoryFile != "" {
+		g.checkDrainHistory()
+	}
 
 	return g
 }
 
 // WrapHTTP wraps an existing HTTP server with graceful shutdown capabilities.
 // The server will be started in a goroutine and tracked for graceful shutdown.
+// server.Addr must be set. The address is bound before WrapHTTP returns, so
+// bind errors are returned and the bound address (e.g. for ":0") is
+// available from Addr.
 func (g *Graceful) WrapHTTP(server *http.Server) error {
-	// Wrap the handler with request tracking
-	if server.Handler != nil {
-		server.Handler = g.httpMiddleware(server.Handler)
+	if server.Addr == "" {
+		return errors.New("gracewrap: WrapHTTP needs server.Addr (use WrapHTTPWithListener or ListenAndWrapHTTP)")
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
 	}
+	g.startHTTP(server, server.Serve, listener)
+	return nil
+}
 
-	// Start the server
-	go func() {
-		g.logger.Printf("HTTP server starting on %s", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			g.logger.Printf("HTTP server error: %v", err)
-		}
-	}()
+// ListenAndWrapHTTP binds addr synchronously, serves handler on it under
+// graceful shutdown management and returns the server and the bound address,
+// which is useful with ":0". Bind errors are returned instead of logged.
+func (g *Graceful) ListenAndWrapHTTP(addr string, handler http.Handler) (*http.Server, string, error) {
+	if addr == "" {
+		return nil, "", errors.New("gracewrap: ListenAndWrapHTTP needs an address")
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, "", err
+	}
+	server := &http.Server{Addr: listener.Addr().String(), Handler: handler}
+	if err := g.WrapHTTPWithListener(server, listener); err != nil {
+		listener.Close()
+		return nil, "", err
+	}
+	return server, listener.Addr().String(), nil
+}
 
-	g.httpServers = append(g.httpServers, server)
+// WrapHTTPTLS wraps an existing HTTPS server with graceful shutdown capabilities.
+// certFile and keyFile may be empty if server.TLSConfig already provides
+// certificates (Certificates or GetCertificate).
+func (g *Graceful) WrapHTTPTLS(server *http.Server, certFile, keyFile string) error {
+	if server.Addr == "" {
+		return errors.New("gracewrap: WrapHTTPTLS needs server.Addr (use WrapHTTPTLSWithListener)")
+	}
+	if certFile == "" && keyFile == "" && !hasTLSCertificates(server.TLSConfig) {
+		return errors.New("gracewrap: WrapHTTPTLS needs cert/key files or a TLSConfig with certificates")
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	g.startHTTP(server, func(l net.Listener) error {
+		return server.ServeTLS(l, certFile, keyFile)
+	}, listener)
+	return nil
+}
+
+// WrapHTTPListeners serves one HTTP server on several listeners, e.g. IPv4
+// and IPv6 or a public and a loopback address, under graceful shutdown
+// management. The bound addresses are available from Addrs.
+func (g *Graceful) WrapHTTPListeners(server *http.Server, listeners ...net.Listener) error {
+	if len(listeners) == 0 {
+		return errors.New("gracewrap: WrapHTTPListeners needs at least one listener")
+	}
+	g.startHTTP(server, server.Serve, listeners...)
 	return nil
 }
 
+// RegisterHTTP registers a server started by someone else (e.g. a framework's
+// own Start method) for graceful shutdown. Its handler is not wrapped: track
+// requests with HTTPMiddleware. Connection tracking and request contexts
+// cancelled at the drain deadline are installed as for WrapHTTP, provided
+// RegisterHTTP is called before the server starts.
+func (g *Graceful) RegisterHTTP(server *http.Server) {
+	g.trackConnState(server)
+	g.setConnContext(server)
+	g.setBaseContext(server)
+	g.httpServers = append(g.httpServers, server)
+}
+
 // WrapHTTPWithListener wraps an HTTP server that's already bound to a listener.
 func (g *Graceful) WrapHTTPWithListener(server *http.Server, listener net.Listener) error {
-	// Wrap the handler with request tracking
+	g.startHTTP(server, server.Serve, listener)
+	return nil
+}
+
+// WrapHTTPTLSWithListener wraps an HTTPS server bound to a plain listener,
+// terminating TLS with server.TLSConfig.
+func (g *Graceful) WrapHTTPTLSWithListener(server *http.Server, listener net.Listener) error {
+	if !hasTLSCertificates(server.TLSConfig) {
+		return errors.New("gracewrap: WrapHTTPTLSWithListener needs a TLSConfig with certificates")
+	}
+	g.startHTTP(server, func(l net.Listener) error {
+		return server.ServeTLS(l, "", "")
+	}, listener)
+	return nil
+}
+
+// startHTTP installs request tracking on server, runs serve on each listener
+// in a goroutine and registers the server for graceful shutdown.
+func (g *Graceful) startHTTP(server *http.Server, serve func(l net.Listener) error, listeners ...net.Listener) {
+	// Wrap the handler with request tracking, unless Chain already did
 	if server.Handler != nil {
-		server.Handler = g.httpMiddleware(server.Handler)
+		if _, tracked := server.Handler.(*trackedHandler); !tracked {
+			server.Handler = g.serverMiddleware(server, server.Handler)
+		}
+		if g.config.EnableH2C {
+			server.Handler = g.wrapH2C(server.Handler)
+		}
 	}
 
+	g.trackConnState(server)
+	g.setConnContext(server)
+	g.setBaseContext(server)
+
 	// Start the server
-	go func() {
-		g.logger.Printf("HTTP server starting on %s", listener.Addr())
-		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
-			g.logger.Printf("HTTP server error: %v", err)
-		}
-	}()
+	addrs := make([]net.Addr, 0, len(listeners))
+	for _, l := range listeners {
+		addrs = append(addrs, l.Addr())
+	}
+	info := g.addServer(NodeHTTP, server, addrs...)
+	for _, listener := range listeners {
+		l := listener
+		go func() {
+			g.logger.Printf("HTTP server %q starting on %s", info.Name, l.Addr())
+			g.serveFailed(info, l.Addr(), serve(l))
+		}()
+	}
 
 	g.httpServers = append(g.httpServers, server)
-	g.listeners = append(g.listeners, listener)
-	return nil
+	g.listeners = append(g.listeners, listeners...)
+}
+
+// hasTLSCertificates reports whether cfg can serve a certificate on its own.
+func hasTLSCertificates(cfg *tls.Config) bool {
+	return cfg != nil && (len(cfg.Certificates) > 0 || cfg.GetCertificate != nil || cfg.GetConfigForClient != nil)
 }
 
 // WrapGRPC wraps an existing gRPC server with graceful shutdown capabilities.
 func (g *Graceful) WrapGRPC(server *grpc.Server, listener net.Listener) error {
-	// Note: This is a limitation - we can't add interceptors to an existing server
-	// Users should create their gRPC server with our interceptors from the start
-	g.logger.Printf("Warning: gRPC server already created. Consider using NewGRPCServer() for full integration.")
+	// Interceptors can't be added to an existing server; it must have been
+	// created with NewGRPCServer or with grpc.StatsHandler(g.StatsHandler())
+	// for its RPCs to be tracked
 
 	// Start the server
+	info := g.addServer(NodeGRPC, server, listener.Addr())
 	go func() {
-		g.logger.Printf("gRPC server starting on %s", listener.Addr())
-		if err := server.Serve(listener); err != nil {
-			g.logger.Printf("gRPC server error: %v", err)
-		}
+		g.logger.Printf("gRPC server %q starting on %s", info.Name, listener.Addr())
+		g.serveFailed(info, listener.Addr(), server.Serve(listener))
 	}()
 
 	g.grpcServers = append(g.grpcServers, server)
 	g.listeners = append(g.listeners, listener)
+	g.syncHealth(g.Ready())
 	return nil
 }
 
 // NewGRPCServer creates a new gRPC server with our interceptors pre-installed.
 // Use this instead of grpc.NewServer() for full graceful shutdown integration.
+// GRPCOption values (WithInterceptorPlacement, WithUnaryInterceptors, ...)
+// may be mixed with regular server options.
 func (g *Graceful) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	gwOpts, opts := splitGRPCOptions(opts)
+	cfg, err := gwOpts.tlsConfig()
+	if err != nil {
+		g.logger.Printf("gRPC TLS setup failed; refusing handshakes: %v", err)
+		cfg = failingTLS(err)
+	}
+	return g.newGRPCServer(gwOpts, cfg, opts)
+}
+
+// newGRPCServer creates a gRPC server with our interceptors and the optional
+// services and TLS configuration selected by gwOpts.
+func (g *Graceful) newGRPCServer(gwOpts grpcOptions, cfg *tls.Config, opts []grpc.ServerOption) *grpc.Server {
+	if cfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	}
+	var server *grpc.Server
+	unary, stream := g.interceptorChains(gwOpts, func() *grpc.Server { return server })
 	opts = append(opts,
-		grpc.ChainUnaryInterceptor(g.grpcUnaryInterceptor),
-		grpc.ChainStreamInterceptor(g.grpcStreamInterceptor),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
 	)
-	return grpc.NewServer(opts...)
+	server = grpc.NewServer(opts...)
+	g.registerServices(server, gwOpts)
+	if gwOpts.stop != nil {
+		g.SetGRPCStopPolicy(server, *gwOpts.stop)
+	}
+	if gwOpts.rejection != nil {
+		g.SetGRPCRejectionPolicy(server, *gwOpts.rejection)
+	}
+	if gwOpts.name != "" {
+		g.SetServerName(server, gwOpts.name)
+	}
+	return server
 }
 
 // ServeGRPC creates a gRPC server with our interceptors and starts it.
+// TLS options (WithTLS, WithClientCA, WithTLSConfig) are validated first, so
+// certificate loading errors are returned before anything is bound.
 func (g *Graceful) ServeGRPC(addr string, opts ...grpc.ServerOption) (*grpc.Server, net.Listener, error) {
+	gwOpts, opts := splitGRPCOptions(opts)
+	cfg, err := gwOpts.tlsConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	server := g.NewGRPCServer(opts...)
+	server := g.newGRPCServer(gwOpts, cfg, opts)
 
+	info := g.addServer(NodeGRPC, server, listener.Addr())
 	go func() {
-		g.logger.Printf("gRPC server starting on %s", addr)
-		if err := server.Serve(listener); err != nil {
-			g.logger.Printf("gRPC server error: %v", err)
-		}
+		g.logger.Printf("gRPC server %q starting on %s", info.Name, addr)
+		g.serveFailed(info, listener.Addr(), server.Serve(listener))
 	}()
 
 	g.grpcServers = append(g.grpcServers, server)
 	g.listeners = append(g.listeners, listener)
+	g.syncHealth(g.Ready())
 	return server, listener, nil
 }
 
-// Wait blocks until a shutdown signal is received, then performs graceful shutdown.
+// Wait blocks until a shutdown signal is received (or another of
+// Config.TerminationSources fires), then performs graceful shutdown.
 // This is the main method you call after setting up your services.
+//
+// Wait may be called from several goroutines, and before or after Shutdown:
+// every call returns once shutdown has completed, with the same error.
+//
+// It returns nil after a clean shutdown. Otherwise the error carries the
+// cause given to Fail and, checkable with errors.Is, ErrForcedStop,
+// ErrDrainDeadlineExceeded or ErrHookFailed for a shutdown that was forced,
+// left requests in flight or had a hook fail.
 func (g *Graceful) Wait(ctx context.Context) error {
-	// Setup signal handling
-	sigCh := make(chan os.Signal, 2)
-	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT)
+	for {
+		if done, err := g.waitOnce(ctx); done {
+			return err
+		}
+		// The drain was cancelled (see CancelDrain); watch again
+		g.logger.Printf("Waiting for the next termination signal")
+	}
+}
+
+// waitOnce waits for one termination trigger and runs shutdown. done is
+// false if the drain was cancelled before shutdown completed.
+func (g *Graceful) waitOnce(ctx context.Context) (done bool, err error) {
+	select {
+	case <-g.done:
+		return true, g.waitErr()
+	default:
+	}
+
+	// Watch signals and any configured termination sources
+	watchCtx, stopWatching := context.WithCancel(ctx)
+	defer stopWatching()
+	triggered := g.watchTermination(watchCtx)
 
 	select {
 	case <-ctx.Done():
 		g.logger.Printf("Context canceled; initiating graceful shutdown")
-		g.shutdown()
-	case sig := <-sigCh:
-		g.logger.Printf("Received signal %v; initiating graceful shutdown", sig)
-		g.shutdown()
+	case reason := <-triggered:
+		g.logger.Printf("Received %s; initiating graceful shutdown", reason)
+	case <-g.stopRequested():
+		g.logger.Printf("Failure reported (%v); initiating graceful shutdown", g.stop.err)
+	case <-g.done:
+		// Shut down elsewhere (Shutdown, ShutdownContext or another Wait)
+		return true, g.waitErr()
 	}
 
-	return nil
+	stopEscalating := g.escalateOnSignal()
+	g.shutdown()
+	stopEscalating()
+
+	select {
+	case <-g.done:
+		return true, g.waitErr()
+	default:
+		return false, nil
+	}
+}
+
+// waitErr returns what Wait returns once shutdown has completed, computed
+// once so that every caller gets the same error.
+func (g *Graceful) waitErr() error {
+	g.result.once.Do(func() {
+		g.result.err = g.failure()
+		if serr := g.shutdownErr(); serr != nil {
+			g.result.err = errors.Join(g.result.err, serr)
+		}
+	})
+	return g.result.err
 }
 
 // Shutdown manually triggers graceful shutdown.
@@ -191,6 +588,59 @@ func (g *Graceful) Shutdown() {
 	g.shutdown()
 }
 
+// ShutdownContext triggers graceful shutdown like Shutdown, bounded by ctx.
+// If ctx ends first, the remaining waits (load balancer delay, drain, hard
+// stop) are cut short: servers are stopped outright and hooks see their
+// context cancelled. It returns once shutdown has completed, with ctx.Err()
+// if it was cut short. Calls made while a shutdown is in progress bound that
+// shutdown.
+//
+// Otherwise it returns nil after a clean shutdown, ErrForcedStop,
+// ErrDrainDeadlineExceeded or ErrHookFailed (joined, see errors.Is) when the
+// shutdown was forced, left requests in flight or had a hook fail, and
+// ErrDrainCancelled if the drain was cancelled with CancelDrain.
+func (g *Graceful) ShutdownContext(ctx context.Context) error {
+	finished := make(chan struct{})
+	go func() {
+		g.shutdown()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		g.cutShort()
+		<-finished
+		return ctx.Err()
+	}
+	select {
+	case <-g.done:
+		return g.shutdownErr()
+	default:
+		return ErrDrainCancelled
+	}
+}
+
+// ShutdownWithTimeout is ShutdownContext with a timeout of d.
+func (g *Graceful) ShutdownWithTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return g.ShutdownContext(ctx)
+}
+
+// ForceShutdown stops everything at once, for when the node is about to go
+// away (e.g. spot reclamation): the load balancer delay and drain are
+// skipped, HTTP servers are closed and gRPC servers stopped outright, and
+// only hooks registered with OnShutdownCritical run. A graceful shutdown
+// already in progress is cut short the same way. It returns once shutdown
+// has completed.
+func (g *Graceful) ForceShutdown() {
+	g.cut.forced.Store(true)
+	g.logger.Printf("Forcing shutdown; skipping drain")
+	g.cutShort()
+	go g.shutdown()
+	<-g.done
+}
+
 // Ready returns the current readiness status.
 func (g *Graceful) Ready() bool {
 	g.readyMu.RLock()
@@ -199,22 +649,37 @@ func (g *Graceful) Ready() bool {
 }
 
 // HealthHandler returns an HTTP handler for health checks.
-// Use this for Kubernetes liveness and readiness probes.
+// Use this for Kubernetes liveness and readiness probes. It returns 503
+// while draining or while a readiness check fails (see AddReadinessCheck).
 func (g *Graceful) HealthHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if g.Ready() {
-			w.WriteHeader(http.StatusOK)
-			_, _ = w.Write([]byte("ready\n"))
-		} else {
+		if !g.Ready() {
 			http.Error(w, "draining", http.StatusServiceUnavailable)
+			return
 		}
+		failed, err := g.readinessFailures(r.Context())
+		if err != nil {
+			http.Error(w, "readiness checks did not finish: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if len(failed) > 0 {
+			http.Error(w, notReadyMessage(failed), http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ready\n"))
 	})
 }
 
 // LivenessHandler returns an HTTP handler for liveness checks.
-// This always returns 200 as long as the process is running.
+// It returns 200 as long as the process is running; with
+// Config.LivenessFailAfterShutdown it returns 500 once shutdown completed.
 func (g *Graceful) LivenessHandler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.config.LivenessFailAfterShutdown && g.shutdownCompleted() {
+			http.Error(w, "shut down", http.StatusInternalServerError)
+			return
+		}
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write([]byte("alive\n"))
 	})
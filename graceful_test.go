@@ -98,9 +98,7 @@ func TestShutdownSequence(t *testing.T) {
 	g.grpcServers = append(g.grpcServers, grpcSrv)
 
 	// bump inflight then decrement in background to test wait
-	g.inflight.mu.Lock()
-	g.inflight.n = 1
-	g.inflight.mu.Unlock()
+	g.incInflight()
 	go func() {
 		time.Sleep(20 * time.Millisecond)
 		g.decInflight()
@@ -0,0 +1,69 @@
+package gracewrap
+
+import "time"
+
+// gracePeriodMargin returns the part of the termination grace period kept
+// free of shutdown work.
+func (c Config) gracePeriodMargin() time.Duration {
+	if c.GracePeriodMargin > 0 {
+		return c.GracePeriodMargin
+	}
+	margin := c.TerminationGracePeriod / 10
+	if margin < time.Second {
+		margin = time.Second
+	}
+	return margin
+}
+
+// shutdownBudget returns the longest a shutdown can take with c: the load
+// balancer delay, drain and hard stop timeouts, with jitter at its maximum.
+func (c Config) shutdownBudget() time.Duration {
+	return c.DrainStartJitter + c.LoadBalancerDelay + c.LoadBalancerDelayJitter + c.DrainTimeout + c.HardStopTimeout
+}
+
+// fitGracePeriod checks the shutdown budget against
+// Config.TerminationGracePeriod. With AutoTuneTimeouts the timeouts are
+// derived from the grace period instead: the load balancer delay is kept (up
+// to half of what is available), a fifth of the rest at most goes to the hard
+// stop and the remainder to the drain.
+func (g *Graceful) fitGracePeriod() {
+	grace := g.config.TerminationGracePeriod
+	if grace <= 0 {
+		return
+	}
+	available := grace - g.config.gracePeriodMargin()
+	if available <= 0 {
+		g.logger.Printf("WARNING: terminationGracePeriodSeconds (%v) leaves no time for shutdown after the %v safety margin; the process will be killed mid-drain",
+			grace, g.config.gracePeriodMargin())
+		return
+	}
+
+	if g.config.AutoTuneTimeouts {
+		jitter := g.config.DrainStartJitter + g.config.LoadBalancerDelayJitter
+		if fixed := g.config.LoadBalancerDelay + jitter; fixed > available/2 {
+			g.config.LoadBalancerDelay = available/2 - jitter
+			if g.config.LoadBalancerDelay < 0 {
+				g.config.LoadBalancerDelay = 0
+			}
+		}
+		rest := available - g.config.LoadBalancerDelay - jitter
+		if rest < 0 {
+			rest = 0
+		}
+		hardStop := g.config.HardStopTimeout
+		if hardStop > rest/5 {
+			hardStop = rest / 5
+		}
+		g.config.HardStopTimeout = hardStop
+		g.config.DrainTimeout = rest - hardStop
+		g.logger.Printf("Timeouts tuned to terminationGracePeriodSeconds %v: load balancer delay %v, drain %v, hard stop %v",
+			grace, g.config.LoadBalancerDelay, g.config.DrainTimeout, g.config.HardStopTimeout)
+		return
+	}
+
+	if budget := g.config.shutdownBudget(); budget > available {
+		g.logger.Printf("WARNING: shutdown can take up to %v (load balancer delay, drain and hard stop timeouts plus jitter) but terminationGracePeriodSeconds is %v; "+
+			"the kubelet will SIGKILL the process mid-drain. Lower the timeouts to fit in %v or enable AutoTuneTimeouts",
+			budget, grace, available)
+	}
+}
@@ -0,0 +1,57 @@
+package gracewrap
+
+import (
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGracePeriodWarning(t *testing.T) {
+	var logs syncBuffer
+	cfg := DefaultConfig()
+	cfg.Logger = log.New(&logs, "", 0)
+	cfg.TerminationGracePeriod = 30 * time.Second
+	New(&cfg)
+
+	// 1s + 25s + 5s does not fit in 30s minus the 3s margin
+	if !strings.Contains(logs.String(), "WARNING: shutdown can take up to 31s") {
+		t.Fatalf("expected a grace period warning, got %q", logs.String())
+	}
+
+	logs = syncBuffer{}
+	cfg.Logger = log.New(&logs, "", 0)
+	cfg.DrainTimeout = 20 * time.Second
+	New(&cfg)
+	if strings.Contains(logs.String(), "WARNING") {
+		t.Fatalf("expected no warning for a fitting budget, got %q", logs.String())
+	}
+}
+
+func TestGracePeriodAutoTune(t *testing.T) {
+	cases := []struct {
+		grace                    time.Duration
+		lbDelay, drain, hardStop time.Duration
+	}{
+		// 27s available: the load balancer delay is kept, the hard stop fits
+		{30 * time.Second, time.Second, 21 * time.Second, 5 * time.Second},
+		// 9s available: the hard stop is capped at a fifth of the rest
+		{10 * time.Second, time.Second, 6400 * time.Millisecond, 1600 * time.Millisecond},
+		// 90s available: the drain grows into the grace period
+		{100 * time.Second, time.Second, 84 * time.Second, 5 * time.Second},
+	}
+	for _, c := range cases {
+		cfg := DefaultConfig()
+		cfg.Logger = log.New(&syncBuffer{}, "", 0)
+		cfg.TerminationGracePeriod = c.grace
+		cfg.AutoTuneTimeouts = true
+		g := New(&cfg)
+		if g.config.LoadBalancerDelay != c.lbDelay || g.config.DrainTimeout != c.drain || g.config.HardStopTimeout != c.hardStop {
+			t.Errorf("grace %v: expected %v/%v/%v, got %v/%v/%v", c.grace, c.lbDelay, c.drain, c.hardStop,
+				g.config.LoadBalancerDelay, g.config.DrainTimeout, g.config.HardStopTimeout)
+		}
+		if budget := g.config.shutdownBudget(); budget > c.grace-g.config.gracePeriodMargin() {
+			t.Errorf("grace %v: tuned budget %v does not fit", c.grace, budget)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Package gracewrapecho integrates gracewrap with the Echo web framework.
+package gracewrapecho
+
+import (
+	"net/http"
+
+	"github.com/imran31415/gracewrap"
+	"github.com/labstack/echo/v4"
+)
+
+// Middleware returns Echo middleware that tracks requests with g and reports
+// echo's route template for per-route metrics. Requests
+// rejected while draining are answered by gracewrap and never reach next.
+func Middleware(g *gracewrap.Graceful) echo.MiddlewareFunc {
+	track := g.HTTPMiddleware()
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var err error
+			track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				orig := c.Response().Writer
+				c.Response().Writer = w
+				defer func() { c.Response().Writer = orig }()
+				err = next(c)
+				gracewrap.SetRoute(r.Context(), c.Path())
+			})).ServeHTTP(c.Response().Writer, c.Request())
+			return err
+		}
+	}
+}
+
+// Register installs Middleware on e and registers e.Server for graceful
+// shutdown, so a server started with e.Start drains with g. /health/live
+// skips the middleware, so liveness probes keep succeeding while draining
+// requests are rejected:
+//
+//	gracewrapecho.Register(g, e)
+//	go e.Start(":8080")
+//	g.Wait(ctx)
+//
+// Call it before e.Start.
+func Register(g *gracewrap.Graceful, e *echo.Echo) {
+	track := Middleware(g)
+	e.Use(func(next echo.HandlerFunc) echo.HandlerFunc {
+		tracked := track(next)
+		return func(c echo.Context) error {
+			if c.Request().URL.Path == "/health/live" {
+				return next(c)
+			}
+			return tracked(c)
+		}
+	})
+	e.GET("/health/ready", echo.WrapHandler(g.HealthHandler()))
+	e.GET("/health/live", echo.WrapHandler(g.LivenessHandler()))
+	e.GET("/metrics", echo.WrapHandler(g.MetricsHandler()))
+	g.RegisterHTTP(e.Server)
+}
@@ -0,0 +1,129 @@
+package gracewrapecho
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/imran31415/gracewrap"
+	"github.com/imran31415/gracewrap/gracewraptest"
+	"github.com/labstack/echo/v4"
+)
+
+func TestMiddlewareRejectsWhileDraining(t *testing.T) {
+	g := gracewrap.New(&gracewrap.Config{DrainTimeout: time.Second, RejectWhileDraining: true})
+	e := echo.New()
+	e.Use(Middleware(g))
+	reached := 0
+	e.GET("/work", func(c echo.Context) error {
+		reached++
+		return c.String(http.StatusOK, "done")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/work", nil))
+	if rec.Code != http.StatusOK || reached != 1 {
+		t.Fatalf("expected request to be served, got %d", rec.Code)
+	}
+
+	g.Shutdown()
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest("GET", "/work", nil))
+	if rec.Code != http.StatusServiceUnavailable || reached != 1 {
+		t.Fatalf("expected rejection while draining, got %d (handler ran %d times)", rec.Code, reached)
+	}
+}
+
+func TestRegisterLivenessAnsweredWhileDraining(t *testing.T) {
+	g := gracewrap.New(&gracewrap.Config{DrainTimeout: time.Second, RejectWhileDraining: true})
+	e := echo.New()
+	e.GET("/work", func(c echo.Context) error { return c.String(http.StatusOK, "done") })
+	Register(g, e)
+	g.Shutdown()
+
+	for path, want := range map[string]int{
+		"/health/live": http.StatusOK,
+		"/work":        http.StatusServiceUnavailable,
+	} {
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d while draining, got %d", path, want, rec.Code)
+		}
+	}
+}
+
+func TestMiddlewareRestoresResponseWriter(t *testing.T) {
+	g := gracewrap.New(nil)
+	e := echo.New()
+	var inner http.ResponseWriter
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest("GET", "/work", nil), rec)
+	h := Middleware(g)(func(c echo.Context) error {
+		inner = c.Response().Writer
+		return nil
+	})
+	if err := h(c); err != nil {
+		t.Fatal(err)
+	}
+	if inner == http.ResponseWriter(rec) {
+		t.Fatal("expected the handler to see gracewrap's writer")
+	}
+	if c.Response().Writer != http.ResponseWriter(rec) {
+		t.Fatal("expected the original writer to be restored after the request")
+	}
+}
+
+func TestRegisterStopsEchoStartOnShutdown(t *testing.T) {
+	g := gracewrap.New(&gracewrap.Config{DrainTimeout: time.Second})
+	e := echo.New()
+	e.HideBanner, e.HidePort = true, true
+	Register(g, e)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	e.Listener = ln
+	stopped := make(chan error, 1)
+	go func() { stopped <- e.Start("") }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/health/ready")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected ready, got %d", resp.StatusCode)
+	}
+
+	g.Shutdown()
+	select {
+	case err := <-stopped:
+		if err != http.ErrServerClosed {
+			t.Fatalf("unexpected Start error %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("e.Start did not return after shutdown")
+	}
+}
+
+func TestRegisterConformance(t *testing.T) {
+	gracewraptest.RunAdapterConformance(t, func(t *testing.T, g *gracewrap.Graceful, handler http.Handler) string {
+		e := echo.New()
+		e.HideBanner, e.HidePort = true, true
+		e.Any("/*", echo.WrapHandler(handler))
+		Register(g, e)
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		e.Listener = ln
+		go func() { _ = e.Start("") }()
+		return "http://" + ln.Addr().String()
+	})
+}
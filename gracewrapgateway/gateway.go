@@ -0,0 +1,92 @@
+// Package gracewrapgateway serves a gRPC server together with its
+// grpc-gateway REST translation under gracewrap.
+package gracewrapgateway
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/imran31415/gracewrap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// NodeGateway is the shutdown node closing the gateway's connections to the
+// gRPC server, after the HTTP servers have stopped.
+const NodeGateway = "grpc-gateway"
+
+// RegisterFunc registers gateway handlers on mux that forward to the gRPC
+// server at endpoint. Generated RegisterXxxHandlerFromEndpoint functions
+// have this signature.
+type RegisterFunc func(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error
+
+// ServeGRPCGateway starts a gRPC server on grpcAddr, with its services
+// registered by services, and a grpc-gateway HTTP server on httpAddr whose
+// handlers are registered by register. /health/ready, /health/live and
+// /metrics are mounted on the HTTP server. Both share g's readiness and
+// drain, and HTTP servers are ordered to stop before gRPC servers so
+// in-flight REST translations are not cut off from their backend.
+func ServeGRPCGateway(g *gracewrap.Graceful, grpcAddr, httpAddr string, services func(s grpc.ServiceRegistrar), register ...RegisterFunc) (*grpc.Server, *http.Server, error) {
+	if err := g.After(gracewrap.NodeHTTP, gracewrap.NodeGRPC); err != nil {
+		return nil, nil, err
+	}
+	if err := g.After(gracewrap.NodeHTTP, NodeGateway); err != nil {
+		return nil, nil, err
+	}
+
+	grpcServer := g.NewGRPCServer()
+	if services != nil {
+		services(grpcServer)
+	}
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Gateway connections to the backend close once the HTTP servers stopped.
+	// Nothing is served until the gateway is registered and both addresses
+	// are bound, so a failure leaves no server running.
+	ctx, cancel := context.WithCancel(context.Background())
+	gw := runtime.NewServeMux()
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	for _, fn := range register {
+		if err := fn(ctx, gw, lis.Addr().String(), opts); err != nil {
+			cancel()
+			lis.Close()
+			return nil, nil, err
+		}
+	}
+	httpLis, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		cancel()
+		lis.Close()
+		return nil, nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/health/ready", g.HealthHandler())
+	mux.Handle("/health/live", g.LivenessHandler())
+	mux.Handle("/metrics", g.MetricsHandler())
+	mux.Handle("/", gw)
+	httpServer := &http.Server{Addr: httpLis.Addr().String(), Handler: mux}
+
+	if err := g.WrapGRPC(grpcServer, lis); err != nil {
+		cancel()
+		lis.Close()
+		httpLis.Close()
+		return nil, nil, err
+	}
+	if err := g.WrapHTTPWithListener(httpServer, httpLis); err != nil {
+		cancel()
+		grpcServer.Stop()
+		httpLis.Close()
+		return nil, nil, err
+	}
+	g.OnShutdown(NodeGateway, func(context.Context) error {
+		cancel()
+		return nil
+	})
+	return grpcServer, httpServer, nil
+}
@@ -0,0 +1,127 @@
+package gracewrapgateway
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/imran31415/gracewrap"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// registerStatus forwards GET /v1/status to the metrics service's Status
+// RPC, as a generated RegisterXxxHandlerFromEndpoint would, after waiting
+// for hold to be closed.
+func registerStatus(hold <-chan struct{}) RegisterFunc {
+	return func(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error {
+		conn, err := grpc.DialContext(ctx, endpoint, opts...)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+		return mux.HandlePath(http.MethodGet, "/v1/status", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			<-hold
+			st := new(structpb.Struct)
+			if err := conn.Invoke(r.Context(), "/"+gracewrap.MetricsServiceName+"/Status", &emptypb.Empty{}, st); err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+				return
+			}
+			b, _ := protojson.Marshal(st)
+			_, _ = w.Write(b)
+		})
+	}
+}
+
+func TestServeGRPCGatewayDrainsGatewayFirst(t *testing.T) {
+	cfg := gracewrap.DefaultConfig()
+	cfg.LoadBalancerDelay = 0
+	cfg.HardStopTimeout = 0
+	cfg.DrainTimeout = 5 * time.Second
+	g := gracewrap.New(&cfg)
+
+	hold := make(chan struct{})
+	_, httpServer, err := ServeGRPCGateway(g, "127.0.0.1:0", "127.0.0.1:0", g.RegisterMetricsService, registerStatus(hold))
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "http://" + g.Addr("http").String()
+	if httpServer == nil {
+		t.Fatal("expected the gateway server")
+	}
+
+	resp, err := http.Get(base + "/health/ready")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected ready, got %v %v", resp, err)
+	}
+	resp.Body.Close()
+
+	// A REST call in flight when shutdown starts still reaches the backend
+	type result struct {
+		code int
+		body string
+	}
+	got := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(base + "/v1/status")
+		if err != nil {
+			got <- result{body: err.Error()}
+			return
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		got <- result{resp.StatusCode, string(b)}
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		g.Shutdown()
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	close(hold)
+
+	r := <-got
+	if r.code != http.StatusOK || !strings.Contains(r.body, "ready") {
+		t.Fatalf("expected the in-flight REST call to succeed, got %d %s", r.code, r.body)
+	}
+	<-done
+}
+
+func TestServeGRPCGatewayLeavesNothingRunningOnError(t *testing.T) {
+	g := gracewrap.New(nil)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	grpcAddr := ln.Addr().String()
+	ln.Close()
+
+	failing := func(context.Context, *runtime.ServeMux, string, []grpc.DialOption) error {
+		return errors.New("register failed")
+	}
+	grpcServer, httpServer, err := ServeGRPCGateway(g, grpcAddr, "127.0.0.1:0", nil, failing)
+	if err == nil || grpcServer != nil || httpServer != nil {
+		t.Fatalf("expected only an error, got %v %v %v", grpcServer, httpServer, err)
+	}
+
+	// The gRPC address is free again
+	ln, err = net.Listen("tcp", grpcAddr)
+	if err != nil {
+		t.Fatalf("expected the gRPC listener to be closed: %v", err)
+	}
+	ln.Close()
+}
@@ -0,0 +1,66 @@
+// Package gracewrapgin integrates gracewrap with the Gin web framework.
+package gracewrapgin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imran31415/gracewrap"
+)
+
+// Middleware returns Gin middleware that tracks requests with g, for engines
+// served outside WrapGin, and reports gin's route template for per-route
+// metrics. Requests rejected while draining are aborted.
+// Prefer WrapGin where possible: it tracks the whole engine, including
+// hijacked (WebSocket) connections.
+func Middleware(g *gracewrap.Graceful) gin.HandlerFunc {
+	track := g.HTTPMiddleware()
+	return func(c *gin.Context) {
+		served := false
+		track(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			served = true
+			c.Request = r
+			c.Next()
+			gracewrap.SetRoute(r.Context(), c.FullPath())
+		})).ServeHTTP(c.Writer, c.Request)
+		if !served {
+			c.Abort()
+		}
+	}
+}
+
+// RouteLabels returns Gin middleware reporting route templates for
+// gracewrap's per-route metrics on engines served with WrapGin. Like any Gin
+// middleware, install it before registering routes.
+func RouteLabels() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		gracewrap.SetRoute(c.Request.Context(), c.FullPath())
+	}
+}
+
+// WrapGin mounts /health/ready and /metrics on engine, starts it on addr
+// and registers the returned server for graceful shutdown. /health/live is
+// answered ahead of request tracking, so liveness probes keep succeeding
+// while draining requests are rejected.
+func WrapGin(g *gracewrap.Graceful, engine *gin.Engine, addr string) (*http.Server, error) {
+	engine.GET("/health/ready", gin.WrapH(g.HealthHandler()))
+	engine.GET("/metrics", gin.WrapH(g.MetricsHandler()))
+
+	server := &http.Server{Addr: addr}
+	server.Handler = g.ChainFor(server, engine, gracewrap.Innermost, liveness(g))
+	if err := g.WrapHTTP(server); err != nil {
+		return nil, err
+	}
+	return server, nil
+}
+
+// liveness answers /health/live itself and passes other requests on.
+func liveness(g *gracewrap.Graceful) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		mux := http.NewServeMux()
+		mux.Handle("/health/live", g.LivenessHandler())
+		mux.Handle("/", next)
+		return mux
+	}
+}
@@ -0,0 +1,115 @@
+package gracewrapgin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/imran31415/gracewrap"
+	"github.com/imran31415/gracewrap/gracewraptest"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func newGraceful() *gracewrap.Graceful {
+	return gracewrap.New(&gracewrap.Config{
+		DrainTimeout:        time.Second,
+		RejectWhileDraining: true,
+	})
+}
+
+func TestMiddlewareRejectsWhileDraining(t *testing.T) {
+	g := newGraceful()
+	engine := gin.New()
+	engine.Use(Middleware(g))
+	reached := 0
+	engine.GET("/work", func(c *gin.Context) {
+		reached++
+		c.String(http.StatusOK, "done")
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest("GET", "/work", nil))
+	if rec.Code != http.StatusOK || reached != 1 {
+		t.Fatalf("expected request to be served, got %d", rec.Code)
+	}
+
+	g.Shutdown()
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest("GET", "/work", nil))
+	if rec.Code != http.StatusServiceUnavailable || reached != 1 {
+		t.Fatalf("expected rejection while draining, got %d (handler ran %d times)", rec.Code, reached)
+	}
+}
+
+func TestWrapGinMountsHealthRoutes(t *testing.T) {
+	g := newGraceful()
+	server, err := WrapGin(g, gin.New(), "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+
+	for _, path := range []string{"/health/ready", "/health/live"} {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, rec.Code)
+		}
+	}
+}
+
+func TestWrapGinLivenessAnsweredWhileDraining(t *testing.T) {
+	g := newGraceful()
+	engine := gin.New()
+	engine.GET("/work", func(c *gin.Context) { c.String(http.StatusOK, "done") })
+	server, err := WrapGin(g, engine, "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Shutdown()
+
+	for path, want := range map[string]int{
+		"/health/live": http.StatusOK,
+		"/work":        http.StatusServiceUnavailable,
+	} {
+		rec := httptest.NewRecorder()
+		server.Handler.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != want {
+			t.Fatalf("%s: expected %d while draining, got %d", path, want, rec.Code)
+		}
+	}
+}
+
+func TestRouteLabelsReportTemplates(t *testing.T) {
+	g := gracewrap.New(&gracewrap.Config{EnableMetrics: true, PrometheusRegistry: prometheus.NewRegistry()})
+	engine := gin.New()
+	engine.Use(RouteLabels())
+	engine.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	h := g.HTTPMiddleware()(engine)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/7", nil))
+
+	rec := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), `route="/users/:id"`) {
+		t.Fatal("expected the gin route template as metric label")
+	}
+}
+
+func TestWrapGinConformance(t *testing.T) {
+	gracewraptest.RunAdapterConformance(t, func(t *testing.T, g *gracewrap.Graceful, handler http.Handler) string {
+		engine := gin.New()
+		engine.Any("/app/*path", gin.WrapH(handler))
+		if _, err := WrapGin(g, engine, "127.0.0.1:0"); err != nil {
+			t.Fatal(err)
+		}
+		return "http://" + g.Addr("http").String() + "/app"
+	})
+}
@@ -0,0 +1,117 @@
+// Package gracewraptest verifies that integrations built on gracewrap
+// (framework adapters, alternative servers) honor its contracts.
+package gracewraptest
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/imran31415/gracewrap"
+)
+
+// Adapter serves handler through the integration under test, under g's
+// graceful shutdown management, and returns the server's base URL
+// (e.g. "http://127.0.0.1:41234").
+type Adapter func(t *testing.T, g *gracewrap.Graceful, handler http.Handler) (baseURL string)
+
+// RunAdapterConformance runs the conformance suite against adapter. It checks
+// that the adapter
+//
+//   - tracks requests: shutdown waits for in-flight requests to complete,
+//   - honors drain: readiness drops and new requests are rejected with 503
+//     once drain starts (Config.RejectWhileDraining),
+//   - stops the server: no new connections are accepted after shutdown.
+func RunAdapterConformance(t *testing.T, adapter Adapter) {
+	t.Run("InflightRequestsComplete", func(t *testing.T) {
+		g := newGraceful(0)
+		release := make(chan struct{})
+		started := make(chan struct{})
+		var once sync.Once
+		url := adapter(t, g, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			once.Do(func() { close(started) })
+			<-release
+			_, _ = io.WriteString(w, "done")
+		}))
+
+		result := make(chan int, 1)
+		go func() { result <- get(url + "/slow") }()
+		<-started
+
+		shutdown := make(chan struct{})
+		go func() {
+			g.Shutdown()
+			close(shutdown)
+		}()
+		select {
+		case <-shutdown:
+			t.Fatal("shutdown completed while a request was in flight: requests are not tracked")
+		case <-time.After(100 * time.Millisecond):
+		}
+
+		close(release)
+		if code := <-result; code != http.StatusOK {
+			t.Fatalf("in-flight request failed during drain: status %d", code)
+		}
+		select {
+		case <-shutdown:
+		case <-time.After(2 * time.Second):
+			t.Fatal("shutdown did not complete after the in-flight request finished")
+		}
+	})
+
+	t.Run("RejectsWhileDraining", func(t *testing.T) {
+		g := newGraceful(300 * time.Millisecond)
+		url := adapter(t, g, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "ok")
+		}))
+		if code := get(url + "/"); code != http.StatusOK {
+			t.Fatalf("expected 200 before drain, got %d", code)
+		}
+
+		go g.Shutdown()
+		deadline := time.Now().Add(time.Second)
+		for g.Ready() {
+			if time.Now().After(deadline) {
+				t.Fatal("readiness did not drop after shutdown started")
+			}
+			time.Sleep(time.Millisecond)
+		}
+		// Still within the load balancer delay: the server accepts but rejects
+		if code := get(url + "/"); code != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503 while draining, got %d", code)
+		}
+	})
+
+	t.Run("StopsAccepting", func(t *testing.T) {
+		g := newGraceful(0)
+		url := adapter(t, g, http.NotFoundHandler())
+		g.Shutdown()
+		if code := get(url + "/"); code != 0 {
+			t.Fatalf("expected connection failure after shutdown, got status %d", code)
+		}
+	})
+}
+
+// newGraceful returns a Graceful with short, test-friendly phases.
+func newGraceful(lbDelay time.Duration) *gracewrap.Graceful {
+	return gracewrap.New(&gracewrap.Config{
+		DrainTimeout:        2 * time.Second,
+		LoadBalancerDelay:   lbDelay,
+		RejectWhileDraining: true,
+	})
+}
+
+// get returns the status code of GET url, or 0 if the request failed.
+func get(url string) int {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode
+}
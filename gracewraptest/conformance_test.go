@@ -0,0 +1,22 @@
+package gracewraptest
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/imran31415/gracewrap"
+)
+
+func TestWrapHTTPConformance(t *testing.T) {
+	RunAdapterConformance(t, func(t *testing.T, g *gracewrap.Graceful, handler http.Handler) string {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		if err := g.WrapHTTPWithListener(&http.Server{Handler: handler}, ln); err != nil {
+			t.Fatal(err)
+		}
+		return "http://" + ln.Addr().String()
+	})
+}
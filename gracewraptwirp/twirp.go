@@ -0,0 +1,49 @@
+// Package gracewraptwirp wires Twirp services into gracewrap's in-flight
+// tracking through Twirp server hooks.
+package gracewraptwirp
+
+import (
+	"context"
+	"errors"
+
+	"github.com/imran31415/gracewrap"
+	"github.com/twitchtv/twirp"
+)
+
+// doneKey is the context key under which a request's done func is stored.
+type doneKey struct{}
+
+// ServerHooks returns Twirp hooks that count each request as in flight from
+// RequestReceived until ResponseSent, so Twirp services delay shutdown and
+// honor drain rejection and in-flight limits like wrapped HTTP handlers.
+// Requests are tracked with kind "twirp" and path "package.Service".
+// Combine them with other hooks using twirp.ChainHooks:
+//
+//	server := pb.NewFooServer(impl, twirp.WithServerHooks(gracewraptwirp.ServerHooks(g)))
+func ServerHooks(g *gracewrap.Graceful) *twirp.ServerHooks {
+	return &twirp.ServerHooks{
+		RequestReceived: func(ctx context.Context) (context.Context, error) {
+			pkg, _ := twirp.PackageName(ctx)
+			svc, _ := twirp.ServiceName(ctx)
+			path := svc
+			if pkg != "" {
+				path = pkg + "." + svc
+			}
+			tracked, done, err := g.TrackRequest(ctx, "twirp", "twirp", path)
+			switch {
+			case errors.Is(err, gracewrap.ErrDraining):
+				return ctx, twirp.NewError(twirp.Unavailable, "draining")
+			case errors.Is(err, gracewrap.ErrOverloaded):
+				return ctx, twirp.NewError(twirp.ResourceExhausted, "too many requests in flight")
+			case err != nil:
+				return ctx, err
+			}
+			return context.WithValue(tracked, doneKey{}, done), nil
+		},
+		ResponseSent: func(ctx context.Context) {
+			if done, ok := ctx.Value(doneKey{}).(func()); ok {
+				done()
+			}
+		},
+	}
+}
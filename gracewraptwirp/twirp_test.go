@@ -0,0 +1,56 @@
+package gracewraptwirp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/imran31415/gracewrap"
+	"github.com/twitchtv/twirp"
+)
+
+func TestServerHooksTrackUntilResponseSent(t *testing.T) {
+	cfg := gracewrap.DefaultConfig()
+	cfg.LoadBalancerDelay = 0
+	cfg.HardStopTimeout = 0
+	cfg.DrainTimeout = 2 * time.Second
+	cfg.RejectWhileDraining = true
+	g := gracewrap.New(&cfg)
+	hooks := ServerHooks(g)
+
+	ctx, err := hooks.RequestReceived(context.Background())
+	if err != nil {
+		t.Fatalf("request received: %v", err)
+	}
+	if n := g.TerminationCost().Inflight; n != 1 {
+		t.Fatalf("expected 1 request in flight, got %d", n)
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		g.Shutdown()
+		close(stopped)
+	}()
+	for g.Ready() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// New requests are rejected with a retryable code while draining
+	rctx, err := hooks.RequestReceived(context.Background())
+	if twerr, ok := err.(twirp.Error); !ok || twerr.Code() != twirp.Unavailable {
+		t.Fatalf("expected Unavailable while draining, got %v", err)
+	}
+	hooks.ResponseSent(rctx)
+
+	select {
+	case <-stopped:
+		t.Fatal("shutdown completed with a Twirp request in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+	hooks.ResponseSent(ctx)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("shutdown did not complete after the response was sent")
+	}
+}
@@ -0,0 +1,78 @@
+package gracewrap
+
+import (
+	"context"
+	"sync"
+)
+
+// Group is an errgroup-like collection of goroutines tied to the Graceful lifecycle.
+// Its context is cancelled when any goroutine returns an error, when the parent
+// context is done, or when shutdown starts. Once drain has begun, Wait returns
+// no later than the drain deadline.
+type Group struct {
+	graceful *Graceful
+	cancel   context.CancelFunc
+
+	wg      sync.WaitGroup
+	errOnce sync.Once
+	err     error
+}
+
+// Group returns a new Group and its derived context, mirroring errgroup.WithContext.
+func (g *Graceful) Group(ctx context.Context) (*Group, context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	gr := &Group{graceful: g, cancel: cancel}
+
+	go func() {
+		select {
+		case <-g.drainStarted():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return gr, ctx
+}
+
+// Go runs fn in a new goroutine. The first non-nil error cancels the group context.
+func (gr *Group) Go(fn func() error) {
+	gr.wg.Add(1)
+	go func() {
+		defer gr.wg.Done()
+		if err := fn(); err != nil {
+			gr.errOnce.Do(func() {
+				gr.err = err
+				gr.cancel()
+			})
+		}
+	}()
+}
+
+// Wait blocks until all goroutines return and reports the first error.
+// If drain has started, Wait gives up at the drain deadline and returns
+// ErrDrainDeadlineExceeded (unless a goroutine already failed); a cancelled
+// drain (see CancelDrain) sets no deadline.
+func (gr *Group) Wait() error {
+	done := make(chan struct{})
+	go func() {
+		gr.wg.Wait()
+		close(done)
+	}()
+
+	stop := make(chan struct{})
+	expired := make(chan struct{})
+	go func() {
+		if gr.graceful.awaitDrainDeadline(0, stop) {
+			close(expired)
+		}
+	}()
+	select {
+	case <-done:
+	case <-expired:
+		gr.errOnce.Do(func() { gr.err = ErrDrainDeadlineExceeded })
+	}
+	close(stop)
+
+	gr.cancel()
+	return gr.err
+}
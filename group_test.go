@@ -0,0 +1,86 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestGroupFirstErrorCancelsContext(t *testing.T) {
+	g := New(nil)
+	gr, ctx := g.Group(context.Background())
+
+	boom := errors.New("boom")
+	gr.Go(func() error { return boom })
+	gr.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+
+	if err := gr.Wait(); !errors.Is(err, boom) {
+		t.Fatalf("expected boom, got %v", err)
+	}
+}
+
+func TestGroupCancelledOnShutdownAndBoundedByDrain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.DrainTimeout = 50 * time.Millisecond
+	g.config.HardStopTimeout = 0
+
+	gr, ctx := g.Group(context.Background())
+	gr.Go(func() error {
+		<-ctx.Done()
+		return nil
+	})
+	stuck := make(chan struct{})
+	defer close(stuck)
+	gr.Go(func() error {
+		<-stuck
+		return nil
+	})
+
+	go g.Shutdown()
+
+	start := time.Now()
+	if err := gr.Wait(); !errors.Is(err, ErrDrainDeadlineExceeded) {
+		t.Fatalf("expected ErrDrainDeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("Wait did not honor drain deadline")
+	}
+}
+
+func TestGroupWaitIgnoresCancelledDrainDeadline(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 100 * time.Millisecond
+	g.config.DrainTimeout = 50 * time.Millisecond
+	g.config.HardStopTimeout = 0
+
+	gr, _ := g.Group(context.Background())
+	release := make(chan struct{})
+	gr.Go(func() error {
+		<-release
+		return nil
+	})
+	res := make(chan error, 1)
+	go func() { res <- gr.Wait() }()
+
+	go g.Shutdown()
+	<-g.drainStarted()
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+
+	// The cancelled drain's deadline passes without ending Wait
+	select {
+	case err := <-res:
+		t.Fatalf("Wait returned after a cancelled drain: %v", err)
+	case <-time.After(300 * time.Millisecond):
+	}
+	close(release)
+	if err := <-res; err != nil {
+		t.Fatalf("expected nil once goroutines return, got %v", err)
+	}
+}
@@ -0,0 +1,15 @@
+package gracewrap
+
+// goAwayGRPC starts a graceful stop of every gRPC server as soon as drain
+// begins (Config.GRPCGoAwayOnDrain). grpc-go sends GOAWAY on each connection
+// right away, so clients stop opening new streams on them while in-flight
+// RPCs finish; the drain deadline still forces a stop as usual.
+func (g *Graceful) goAwayGRPC() {
+	if !g.config.GRPCGoAwayOnDrain || len(g.grpcServers) == 0 {
+		return
+	}
+	for _, srv := range g.grpcServers {
+		go srv.GracefulStop()
+	}
+	g.logger.Printf("Sent GOAWAY to gRPC connections")
+}
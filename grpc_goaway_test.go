@@ -0,0 +1,53 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGRPCGoAwayOnDrain(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.GRPCGoAwayOnDrain = true
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	srv := g.NewGRPCServer()
+	g.RegisterMetricsService(srv)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if err := g.WrapGRPC(srv, ln); err != nil {
+		t.Fatalf("wrap grpc: %v", err)
+	}
+	defer g.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.Invoke(ctx, "/"+MetricsServiceName+"/Status", &emptypb.Empty{}, new(structpb.Struct)); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+
+	g.beginDrain(time.Now().Add(time.Second))
+	g.goAwayGRPC()
+
+	// The client leaves READY once it receives GOAWAY
+	for conn.GetState() == connectivity.Ready {
+		if !conn.WaitForStateChange(ctx, connectivity.Ready) {
+			t.Fatal("connection stayed ready after drain started")
+		}
+	}
+}
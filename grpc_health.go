@@ -0,0 +1,96 @@
+package gracewrap
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// WithHealthService registers the standard grpc.health.v1.Health service on
+// the server. The overall status ("") and every service registered on the
+// server report SERVING while ready and flip to NOT_SERVING the moment drain
+// begins, so gRPC probes and client-side health checking stop routing to the
+// pod without an HTTP health endpoint. Watch streams receive the transition
+// as it happens, then end with UNAVAILABLE once servers begin stopping so
+// they do not hold up the drain. Health RPCs are never tracked or rejected.
+func WithHealthService() GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.health = true }}
+}
+
+// grpcHealth is a health service bound to the server it reports on.
+type grpcHealth struct {
+	server *grpc.Server
+	hs     *health.Server
+}
+
+// registerHealth installs a health service on server and records it.
+func (g *Graceful) registerHealth(server *grpc.Server) {
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(server, &drainingHealth{Server: hs, graceful: g})
+	g.health.mu.Lock()
+	g.health.list = append(g.health.list, grpcHealth{server: server, hs: hs})
+	g.health.mu.Unlock()
+	g.syncHealth(g.Ready())
+}
+
+// syncHealth sets every service on health-enabled servers to match ready.
+// Services registered after the server was created are picked up here, so
+// it also runs when a server starts serving.
+func (g *Graceful) syncHealth(ready bool) {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if ready {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	g.health.mu.Lock()
+	defer g.health.mu.Unlock()
+	for _, h := range g.health.list {
+		h.hs.SetServingStatus("", status)
+		for name := range h.server.GetServiceInfo() {
+			h.hs.SetServingStatus(name, status)
+		}
+	}
+}
+
+// drainingHealth ends health Watch streams once servers begin stopping.
+type drainingHealth struct {
+	*health.Server
+	graceful *Graceful
+}
+
+// Watch streams status changes like health.Server, until servers begin
+// stopping.
+func (h *drainingHealth) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	stopping := h.graceful.stoppingStarted()
+	go func() {
+		select {
+		case <-stopping:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := h.Server.Watch(req, &watchStream{Health_WatchServer: stream, ctx: ctx})
+	select {
+	case <-stopping:
+		return status.Error(codes.Unavailable, "server stopping")
+	default:
+		return err
+	}
+}
+
+// watchStream overrides the context of a Watch stream.
+type watchStream struct {
+	healthpb.Health_WatchServer
+	ctx context.Context
+}
+
+// Context returns the overridden stream context.
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,131 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthServiceFlipsOnDrain(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	srv := g.NewGRPCServer(WithHealthService())
+	g.RegisterMetricsService(srv)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if err := g.WrapGRPC(srv, ln); err != nil {
+		t.Fatalf("wrap grpc: %v", err)
+	}
+	defer g.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	check := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("check %q: %v", service, err)
+		}
+		return resp.Status
+	}
+
+	for _, svc := range []string{"", MetricsServiceName} {
+		if got := check(svc); got != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("expected %q SERVING, got %v", svc, got)
+		}
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "unknown.Service"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for unknown service, got %v", err)
+	}
+
+	g.setReady(false)
+	for _, svc := range []string{"", MetricsServiceName} {
+		if got := check(svc); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Fatalf("expected %q NOT_SERVING after drain, got %v", svc, got)
+		}
+	}
+}
+
+func TestNoHealthServiceByDefault(t *testing.T) {
+	g := newTestGraceful(t)
+	srv := g.NewGRPCServer()
+	if _, ok := srv.GetServiceInfo()["grpc.health.v1.Health"]; ok {
+		t.Fatal("health service registered without WithHealthService")
+	}
+}
+
+func TestHealthWatchFollowsDrain(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 200 * time.Millisecond
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 5 * time.Second
+	g.config.RejectWhileDraining = true
+
+	_, ln, err := g.ServeGRPC("127.0.0.1:0", WithHealthService())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	watch, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := watch.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %v %v", resp, err)
+	}
+
+	start := time.Now()
+	shutdownDone := make(chan struct{})
+	go func() {
+		g.Shutdown()
+		close(shutdownDone)
+	}()
+
+	resp, err := watch.Recv()
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING, got %v %v", resp, err)
+	}
+	if d := time.Since(start); d >= g.config.LoadBalancerDelay {
+		t.Fatalf("NOT_SERVING arrived after %v, not before servers stopped", d)
+	}
+
+	// Probes are answered, not rejected, while draining
+	if resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING from Check while draining, got %v %v", resp, err)
+	}
+
+	if _, err := watch.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected the watch to end with Unavailable, got %v", err)
+	}
+	<-shutdownDone
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("watch stream held up the drain for %v", d)
+	}
+}
@@ -0,0 +1,109 @@
+package gracewrap
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// MetricsServiceName is the fully-qualified name of the gRPC metrics service.
+const MetricsServiceName = "gracewrap.v1.Metrics"
+
+// metricsServer serves gracewrap status and metrics over gRPC.
+type metricsServer interface {
+	status(ctx context.Context) (*structpb.Struct, error)
+	metricsText(ctx context.Context) (*wrapperspb.StringValue, error)
+}
+
+// RegisterMetricsService registers the gracewrap.v1.Metrics service on s, so
+// gRPC-only services can expose status and metrics without an HTTP port:
+//
+//	Status(google.protobuf.Empty) returns (google.protobuf.Struct)
+//	Metrics(google.protobuf.Empty) returns (google.protobuf.StringValue)
+//
+// Metrics returns the Prometheus text exposition format and fails with
+// codes.Unavailable when metrics are not enabled.
+func (g *Graceful) RegisterMetricsService(s grpc.ServiceRegistrar) {
+	s.RegisterService(&metricsServiceDesc, metricsServer(g))
+}
+
+// status implements the Status RPC.
+func (g *Graceful) status(ctx context.Context) (*structpb.Struct, error) {
+	n := g.inflightCount()
+
+	return structpb.NewStruct(map[string]interface{}{
+		"ready":          g.Ready(),
+		"inflight":       float64(n),
+		"uptime_seconds": time.Since(g.started).Seconds(),
+	})
+}
+
+// metricsText implements the Metrics RPC.
+func (g *Graceful) metricsText(ctx context.Context) (*wrapperspb.StringValue, error) {
+	if !g.config.EnableMetrics || g.metrics == nil {
+		return nil, status.Error(codes.Unavailable, "metrics not enabled")
+	}
+	families, err := g.metrics.gatherer.Gather()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, mf := range families {
+		if err := enc.Encode(mf); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	}
+	return wrapperspb.String(buf.String()), nil
+}
+
+var metricsServiceDesc = grpc.ServiceDesc{
+	ServiceName: MetricsServiceName,
+	HandlerType: (*metricsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Status",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(emptypb.Empty)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(metricsServer).status(ctx)
+				}
+				if interceptor == nil {
+					return handler(ctx, in)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + MetricsServiceName + "/Status"}
+				return interceptor(ctx, in, info, handler)
+			},
+		},
+		{
+			MethodName: "Metrics",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+				in := new(emptypb.Empty)
+				if err := dec(in); err != nil {
+					return nil, err
+				}
+				handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+					return srv.(metricsServer).metricsText(ctx)
+				}
+				if interceptor == nil {
+					return handler(ctx, in)
+				}
+				info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + MetricsServiceName + "/Metrics"}
+				return interceptor(ctx, in, info, handler)
+			},
+		},
+	},
+	Streams: []grpc.StreamDesc{},
+}
@@ -0,0 +1,82 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestMetricsServiceOverGRPC(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	srv := g.NewGRPCServer()
+	g.RegisterMetricsService(srv)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if err := g.WrapGRPC(srv, ln); err != nil {
+		t.Fatalf("wrap grpc: %v", err)
+	}
+	defer g.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	st := new(structpb.Struct)
+	if err := conn.Invoke(ctx, "/"+MetricsServiceName+"/Status", &emptypb.Empty{}, st); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !st.Fields["ready"].GetBoolValue() {
+		t.Fatalf("expected ready, got %v", st)
+	}
+
+	text := new(wrapperspb.StringValue)
+	if err := conn.Invoke(ctx, "/"+MetricsServiceName+"/Metrics", &emptypb.Empty{}, text); err != nil {
+		t.Fatalf("metrics: %v", err)
+	}
+	if !strings.Contains(text.Value, "gracewrap_grpc_requests_total") {
+		t.Fatalf("expected metrics text, got %q", text.Value)
+	}
+}
+
+func TestGRPCMethodMetrics(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.GRPCMethodMetrics = true
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/svc/Get"}
+	_, _ = g.grpcUnaryInterceptor(context.Background(), nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	_, _ = g.grpcUnaryInterceptor(context.Background(), nil, info,
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "missing")
+		})
+	_ = g.grpcStreamInterceptor(nil, &fakeServerStream{}, &grpc.StreamServerInfo{FullMethod: "/svc/Watch"},
+		func(srv interface{}, ss grpc.ServerStream) error { return nil })
+
+	for _, want := range []string{
+		`gracewrap_grpc_method_requests_total{code="OK",method="/svc/Get"} 1`,
+		`gracewrap_grpc_method_requests_total{code="NotFound",method="/svc/Get"} 1`,
+		`gracewrap_grpc_method_requests_total{code="OK",method="/svc/Watch"} 1`,
+		`gracewrap_grpc_request_duration_seconds_count{code="NotFound",method="/svc/Get"} 1`,
+	} {
+		assertMetric(t, g, want)
+	}
+}
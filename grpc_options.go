@@ -0,0 +1,131 @@
+package gracewrap
+
+import (
+	"context"
+	"crypto/tls"
+
+	"google.golang.org/grpc"
+	channelzsvc "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/reflection"
+)
+
+// grpcOptions collects gracewrap-specific settings for NewGRPCServer.
+type grpcOptions struct {
+	placement    Placement
+	placementSet bool
+	unary        []grpc.UnaryServerInterceptor
+	stream       []grpc.StreamServerInterceptor
+	health       bool
+	reflection   bool
+	channelz     bool
+	stop         *GRPCStopPolicy
+	rejection    *RejectionPolicy
+	name         string
+
+	// TLS settings (see WithTLS)
+	certFile, keyFile string
+	clientCAFile      string
+	tls               *tls.Config
+}
+
+// GRPCOption is a gracewrap setting passed to NewGRPCServer or ServeGRPC
+// alongside regular grpc.ServerOptions. It is a no-op for grpc itself.
+type GRPCOption struct {
+	grpc.EmptyServerOption
+	apply func(*grpcOptions)
+}
+
+// WithInterceptorPlacement places gracewrap's interceptors first (Outermost)
+// or last (Innermost) in the merged interceptor chain, overriding
+// Config.InterceptorPlacement.
+//
+// Interceptors passed with grpc.UnaryInterceptor or grpc.StreamInterceptor
+// always run before every chained one, gracewrap's included; use
+// WithUnaryInterceptors and WithStreamInterceptors for a deterministic order.
+func WithInterceptorPlacement(p Placement) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.placement, o.placementSet = p, true }}
+}
+
+// WithUnaryInterceptors merges interceptors into gracewrap's unary chain, in
+// order, on the side opposite gracewrap's placement. Prefer this over passing
+// grpc.ChainUnaryInterceptor yourself, whose position relative to gracewrap
+// depends on option order.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryServerInterceptor) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.unary = append(o.unary, interceptors...) }}
+}
+
+// WithStreamInterceptors merges interceptors into gracewrap's stream chain,
+// like WithUnaryInterceptors.
+func WithStreamInterceptors(interceptors ...grpc.StreamServerInterceptor) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.stream = append(o.stream, interceptors...) }}
+}
+
+// splitGRPCOptions separates gracewrap options from grpc server options.
+func splitGRPCOptions(opts []grpc.ServerOption) (grpcOptions, []grpc.ServerOption) {
+	var o grpcOptions
+	rest := make([]grpc.ServerOption, 0, len(opts))
+	for _, opt := range opts {
+		if gw, ok := opt.(GRPCOption); ok {
+			if gw.apply != nil {
+				gw.apply(&o)
+			}
+			continue
+		}
+		rest = append(rest, opt)
+	}
+	return o, rest
+}
+
+// interceptorChains returns the merged unary and stream chains. server
+// returns the server the chains are installed on, once it exists.
+func (g *Graceful) interceptorChains(o grpcOptions, server func() *grpc.Server) ([]grpc.UnaryServerInterceptor, []grpc.StreamServerInterceptor) {
+	if !o.placementSet {
+		o.placement = g.config.InterceptorPlacement
+	}
+	tracked := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return g.trackUnary(server(), ctx, req, info, handler)
+	}
+	trackedStream := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return g.trackStream(server(), srv, ss, info, handler)
+	}
+
+	unary := make([]grpc.UnaryServerInterceptor, 0, len(o.unary)+1)
+	stream := make([]grpc.StreamServerInterceptor, 0, len(o.stream)+1)
+	if o.placement == Outermost {
+		unary = append(unary, tracked)
+		stream = append(stream, trackedStream)
+	}
+	unary = append(unary, o.unary...)
+	stream = append(stream, o.stream...)
+	if o.placement == Innermost {
+		unary = append(unary, tracked)
+		stream = append(stream, trackedStream)
+	}
+	return unary, stream
+}
+
+// WithReflection registers the gRPC server reflection service, so tools
+// such as grpcurl can list and call services on the wrapped server.
+func WithReflection() GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.reflection = true }}
+}
+
+// WithChannelz registers the channelz service, exposing connection and
+// stream internals to channelz UIs. Registering it turns channelz on for
+// the whole process.
+func WithChannelz() GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.channelz = true }}
+}
+
+// registerServices installs the optional services selected by o on server.
+func (g *Graceful) registerServices(server *grpc.Server, o grpcOptions) {
+	if o.health {
+		g.registerHealth(server)
+	}
+	if o.reflection {
+		reflection.Register(server)
+	}
+	if o.channelz {
+		channelzsvc.RegisterChannelzServiceToServer(server)
+	}
+}
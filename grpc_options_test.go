@@ -0,0 +1,92 @@
+package gracewrap
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestInterceptorPlacementAndMerge(t *testing.T) {
+	g := New(nil)
+
+	var order []string
+	user := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			g.inflight.mu.Lock()
+			if g.inflight.n > 0 {
+				name += "(tracked)"
+			}
+			g.inflight.mu.Unlock()
+			order = append(order, name)
+			return handler(ctx, req)
+		}
+	}
+
+	run := func(opts ...grpc.ServerOption) []string {
+		order = nil
+		o, rest := splitGRPCOptions(opts)
+		if len(rest) != 0 {
+			t.Fatalf("gracewrap options leaked into grpc options")
+		}
+		unary, _ := g.interceptorChains(o, func() *grpc.Server { return nil })
+		var call grpc.UnaryHandler = func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+		for i := len(unary) - 1; i >= 0; i-- {
+			next, ic := call, unary[i]
+			call = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return ic(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/svc/M"}, next)
+			}
+		}
+		_, _ = call(context.Background(), nil)
+		return order
+	}
+
+	got := run(WithUnaryInterceptors(user("auth"), user("log")))
+	if len(got) != 2 || got[0] != "auth(tracked)" || got[1] != "log(tracked)" {
+		t.Fatalf("outermost: unexpected order %v", got)
+	}
+
+	got = run(WithInterceptorPlacement(Innermost), WithUnaryInterceptors(user("auth")))
+	if len(got) != 1 || got[0] != "auth" {
+		t.Fatalf("innermost: unexpected order %v", got)
+	}
+
+	g.config.InterceptorPlacement = Innermost
+	got = run(WithUnaryInterceptors(user("auth")))
+	if len(got) != 1 || got[0] != "auth" {
+		t.Fatalf("config innermost: unexpected order %v", got)
+	}
+	got = run(WithInterceptorPlacement(Outermost), WithUnaryInterceptors(user("auth")))
+	if len(got) != 1 || got[0] != "auth(tracked)" {
+		t.Fatalf("option overrides config: unexpected order %v", got)
+	}
+}
+
+func TestNewGRPCServerAcceptsGracewrapOptions(t *testing.T) {
+	g := New(nil)
+	srv := g.NewGRPCServer(WithInterceptorPlacement(Innermost), grpc.MaxRecvMsgSize(1024))
+	if srv == nil {
+		t.Fatal("expected server")
+	}
+	srv.Stop()
+}
+
+func TestReflectionAndChannelzOptions(t *testing.T) {
+	g := New(nil)
+
+	srv := g.NewGRPCServer(WithReflection(), WithChannelz())
+	info := srv.GetServiceInfo()
+	for _, name := range []string{
+		"grpc.reflection.v1.ServerReflection",
+		"grpc.reflection.v1alpha.ServerReflection",
+		"grpc.channelz.v1.Channelz",
+	} {
+		if _, ok := info[name]; !ok {
+			t.Fatalf("expected %s to be registered, got %v", name, info)
+		}
+	}
+
+	if n := len(g.NewGRPCServer().GetServiceInfo()); n != 0 {
+		t.Fatalf("expected no services without options, got %d", n)
+	}
+}
@@ -0,0 +1,67 @@
+package gracewrap
+
+import (
+	"context"
+
+	"google.golang.org/grpc/stats"
+)
+
+// StatsHandler returns a grpc stats.Handler that tracks in-flight RPCs and
+// records metrics, for servers that were created without NewGRPCServer:
+//
+//	srv := grpc.NewServer(grpc.StatsHandler(g.StatsHandler()))
+//
+// Unlike the interceptors it cannot reject or shed RPCs, so
+// Config.RejectWhileDraining and MaxInflight do not apply. Do not combine it
+// with NewGRPCServer, which would track every RPC twice.
+func (g *Graceful) StatsHandler() stats.Handler {
+	return &statsHandler{g: g}
+}
+
+// statsHandler implements stats.Handler on top of trackRoute.
+type statsHandler struct {
+	g *Graceful
+}
+
+// statsDoneKey is the context key under which an RPC's done func is stored.
+type statsDoneKey struct{}
+
+// statsMethodKey is the context key under which an RPC's full method is stored.
+type statsMethodKey struct{}
+
+// TagRPC starts tracking an RPC. The method kind is not known yet, so RPCs
+// are tracked as method "rpc".
+func (h *statsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	ctx = context.WithValue(ctx, statsMethodKey{}, info.FullMethodName)
+	policy := h.g.routePolicy(info.FullMethodName)
+	if policy != nil && policy.Untracked {
+		return ctx
+	}
+	ctx, done := h.g.trackRoute(ctx, policy, "grpc", "rpc", info.FullMethodName)
+	if h.g.metrics != nil {
+		h.g.metrics.incGRPC()
+	}
+	return context.WithValue(ctx, statsDoneKey{}, done)
+}
+
+// HandleRPC stops tracking an RPC when it ends.
+func (h *statsHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	end, ok := s.(*stats.End)
+	if !ok {
+		return
+	}
+	if info, ok := ctx.Value(statsMethodKey{}).(string); ok {
+		h.g.observeGRPCMethod(info, end.Error, end.BeginTime)
+	}
+	if done, ok := ctx.Value(statsDoneKey{}).(func()); ok {
+		done()
+	}
+}
+
+// TagConn implements stats.Handler.
+func (h *statsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn implements stats.Handler.
+func (h *statsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,58 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestStatsHandlerTracksExistingServer(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	ended := make(chan RequestInfo, 1)
+	g.config.OnRequestEnd = func(ri RequestInfo) { ended <- ri }
+
+	srv := grpc.NewServer(grpc.StatsHandler(g.StatsHandler()))
+	g.RegisterMetricsService(srv)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if err := g.WrapGRPC(srv, ln); err != nil {
+		t.Fatalf("wrap grpc: %v", err)
+	}
+	defer g.Shutdown()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.Invoke(ctx, "/"+MetricsServiceName+"/Status", &emptypb.Empty{}, new(structpb.Struct)); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+
+	// End is reported after the response is written
+	select {
+	case ri := <-ended:
+		if ri.Path != "/"+MetricsServiceName+"/Status" {
+			t.Fatalf("unexpected tracked RPC: %+v", ri)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("RPC end was not tracked")
+	}
+	if g.requestsSeen() != 1 || g.inflightCount() != 0 {
+		t.Fatalf("expected one finished RPC, seen %d in flight %d", g.requestsSeen(), g.inflightCount())
+	}
+}
@@ -0,0 +1,89 @@
+package gracewrap
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// GRPCStopMode selects how a gRPC server is stopped during shutdown.
+type GRPCStopMode int
+
+const (
+	// GracefulThenStop lets in-flight RPCs finish with GracefulStop and
+	// forces Stop at the server's drain deadline (the default).
+	GracefulThenStop GRPCStopMode = iota
+	// StopImmediately closes the server with Stop as soon as servers are
+	// stopped, cancelling its in-flight RPCs. Suited to internal admin or
+	// debug servers whose calls are not worth waiting for.
+	StopImmediately
+)
+
+// GRPCStopPolicy overrides how one gRPC server is stopped.
+type GRPCStopPolicy struct {
+	// How long the server may drain once servers start stopping (0 uses
+	// Config.DrainTimeout; it never extends past the global drain deadline)
+	DrainTimeout time.Duration
+	// How the server is stopped (defaults to GracefulThenStop)
+	Mode GRPCStopMode
+}
+
+// SetGRPCStopPolicy overrides the drain deadline and stop mode of server.
+func (g *Graceful) SetGRPCStopPolicy(server *grpc.Server, p GRPCStopPolicy) {
+	g.grpcStop.mu.Lock()
+	defer g.grpcStop.mu.Unlock()
+	if g.grpcStop.byServer == nil {
+		g.grpcStop.byServer = make(map[*grpc.Server]GRPCStopPolicy)
+	}
+	g.grpcStop.byServer[server] = p
+}
+
+// WithStopPolicy sets the stop policy of the server created by NewGRPCServer
+// or ServeGRPC (see SetGRPCStopPolicy).
+func WithStopPolicy(p GRPCStopPolicy) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.stop = &p }}
+}
+
+// grpcStopPolicy returns the policy for server (zero means the default).
+func (g *Graceful) grpcStopPolicy(server *grpc.Server) GRPCStopPolicy {
+	g.grpcStop.mu.Lock()
+	defer g.grpcStop.mu.Unlock()
+	return g.grpcStop.byServer[server]
+}
+
+// stopGRPC stops srv according to its stop policy, forcing a stop once ctx
+// or the server's own drain deadline expires. It returns the context error
+// when the stop had to be forced.
+func (g *Graceful) stopGRPC(ctx context.Context, srv *grpc.Server) error {
+	name := g.serverName(srv)
+	p := g.grpcStopPolicy(srv)
+	if p.Mode == StopImmediately || g.cut.forced.Load() {
+		srv.Stop()
+		g.logger.Printf("gRPC server %q stopped immediately", name)
+		return nil
+	}
+	if p.DrainTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.DrainTimeout)
+		defer cancel()
+	}
+
+	// Start graceful stop in background
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+
+	// Force stop if deadline exceeded
+	select {
+	case <-done:
+		g.logger.Printf("gRPC server %q graceful shutdown completed", name)
+		return nil
+	case <-ctx.Done():
+		g.logger.Printf("gRPC server %q deadline reached; forcing stop", name)
+		srv.Stop()
+		return ctx.Err()
+	}
+}
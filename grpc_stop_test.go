@@ -0,0 +1,88 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// blockingServer serves /test.Block/Wait, which returns once its context is
+// cancelled, and reports each call on started.
+func blockingServer(t *testing.T, g *Graceful, started chan<- struct{}, opts ...grpc.ServerOption) string {
+	t.Helper()
+	srv := g.NewGRPCServer(opts...)
+	srv.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "test.Block",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Wait",
+			Handler: func(_ interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				if err := dec(new(emptypb.Empty)); err != nil {
+					return nil, err
+				}
+				started <- struct{}{}
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		}},
+	}, struct{}{})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if err := g.WrapGRPC(srv, ln); err != nil {
+		t.Fatalf("wrap grpc: %v", err)
+	}
+	return ln.Addr().String()
+}
+
+// callBlocking starts a blocking call against addr and returns its result.
+func callBlocking(t *testing.T, addr string) <-chan error {
+	t.Helper()
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	res := make(chan error, 1)
+	go func() {
+		res <- conn.Invoke(context.Background(), "/test.Block/Wait", &emptypb.Empty{}, new(emptypb.Empty))
+	}()
+	return res
+}
+
+func TestGRPCStopPolicy(t *testing.T) {
+	g := newTestGraceful(t)
+	started := make(chan struct{}, 2)
+	mainAddr := blockingServer(t, g, started, WithStopPolicy(GRPCStopPolicy{DrainTimeout: 200 * time.Millisecond}))
+	adminAddr := blockingServer(t, g, started, WithStopPolicy(GRPCStopPolicy{Mode: StopImmediately}))
+	mainRes, adminRes := callBlocking(t, mainAddr), callBlocking(t, adminAddr)
+	<-started
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	g.stopGRPC(ctx, g.grpcServers[1])
+	if d := time.Since(start); d > 100*time.Millisecond {
+		t.Fatalf("admin server took %v to stop, want immediate", d)
+	}
+	if err := <-adminRes; err == nil {
+		t.Fatal("admin RPC succeeded, want it cancelled by Stop")
+	}
+
+	start = time.Now()
+	g.stopGRPC(ctx, g.grpcServers[0])
+	if d := time.Since(start); d < 200*time.Millisecond || d > 2*time.Second {
+		t.Fatalf("main server took %v to stop, want its own 200ms deadline", d)
+	}
+	if err := <-mainRes; err == nil {
+		t.Fatal("main RPC succeeded, want it cancelled at the deadline")
+	}
+}
@@ -0,0 +1,72 @@
+package gracewrap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// WithTLS serves the gRPC server over TLS with the certificate and key in
+// certFile and keyFile. ServeGRPC returns loading errors; NewGRPCServer logs
+// them and fails every handshake rather than serve in plaintext.
+func WithTLS(certFile, keyFile string) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.certFile, o.keyFile = certFile, keyFile }}
+}
+
+// WithClientCA requires clients to present a certificate signed by a CA in
+// caFile (mutual TLS). It needs WithTLS or WithTLSConfig.
+func WithClientCA(caFile string) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.clientCAFile = caFile }}
+}
+
+// WithTLSConfig serves the gRPC server over TLS with cfg, e.g. a
+// CertManager's TLSConfig for certificates reloaded from disk.
+func WithTLSConfig(cfg *tls.Config) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.tls = cfg }}
+}
+
+// tlsConfig builds the server TLS configuration selected by o, or nil when
+// TLS was not requested.
+func (o grpcOptions) tlsConfig() (*tls.Config, error) {
+	var cfg *tls.Config
+	switch {
+	case o.tls != nil:
+		cfg = o.tls.Clone()
+	case o.certFile != "" || o.keyFile != "":
+		cert, err := tls.LoadX509KeyPair(o.certFile, o.keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("gracewrap: loading gRPC certificate: %w", err)
+		}
+		cfg = &tls.Config{Certificates: []tls.Certificate{cert}}
+	case o.clientCAFile != "":
+		return nil, errors.New("gracewrap: WithClientCA needs WithTLS or WithTLSConfig")
+	default:
+		return nil, nil
+	}
+	if !hasTLSCertificates(cfg) {
+		return nil, errors.New("gracewrap: gRPC TLS config has no certificates")
+	}
+
+	if o.clientCAFile != "" {
+		pem, err := os.ReadFile(o.clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("gracewrap: loading gRPC client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("gracewrap: no certificates in client CA file %s", o.clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// failingTLS returns a TLS configuration whose handshakes all fail with err.
+func failingTLS(err error) *tls.Config {
+	return &tls.Config{GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return nil, err
+	}}
+}
@@ -0,0 +1,66 @@
+package gracewrap
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestServeGRPCMutualTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir(), "server")
+	clientCert, clientKey := writeSelfSignedCert(t, t.TempDir(), "client")
+
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	if _, _, err := g.ServeGRPC("127.0.0.1:0", WithTLS("missing.pem", "missing.key")); err == nil {
+		t.Fatal("expected an error for missing certificate files")
+	}
+	if _, _, err := g.ServeGRPC("127.0.0.1:0", WithClientCA(clientCert)); err == nil {
+		t.Fatal("expected an error for a client CA without a server certificate")
+	}
+
+	_, ln, err := g.ServeGRPC("127.0.0.1:0", WithTLS(certFile, keyFile), WithClientCA(clientCert), WithHealthService())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+
+	pemBytes, err := os.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(pemBytes)
+	cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(cfg *tls.Config) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithTransportCredentials(credentials.NewTLS(cfg)))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		return err
+	}
+
+	if err := check(&tls.Config{RootCAs: roots, Certificates: []tls.Certificate{cert}}); err != nil {
+		t.Fatalf("expected mTLS client to connect: %v", err)
+	}
+	if err := check(&tls.Config{RootCAs: roots}); err == nil {
+		t.Fatal("expected a client without a certificate to be refused")
+	}
+}
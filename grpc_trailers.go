@@ -0,0 +1,29 @@
+package gracewrap
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// drainTrailer returns the trailer to attach to an RPC ending with err
+// while draining, and err converted per Config.GRPCDrainUnavailable. It
+// returns a nil trailer when nothing should be attached.
+func (g *Graceful) drainTrailer(ctx context.Context, err error) (metadata.MD, error) {
+	if !g.draining() {
+		return nil, err
+	}
+	var md metadata.MD
+	if g.config.GRPCDrainTrailers {
+		md = metadata.Pairs("grpc-drain", "true")
+	}
+	// The RPC was cut short by drain (budget, policy or deadline
+	// cancellation): ask the client to retry elsewhere right away
+	if g.config.GRPCDrainUnavailable && err != nil && ctx.Err() != nil {
+		md = metadata.Join(md, metadata.Pairs("grpc-retry-pushback-ms", "0"))
+		err = status.Error(codes.Unavailable, "server draining; retry on another backend")
+	}
+	return md, err
+}
@@ -0,0 +1,69 @@
+package gracewrap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// trailerStream records trailers set on a unary RPC.
+type trailerStream struct{ trailer metadata.MD }
+
+func (s *trailerStream) Method() string               { return "/svc/Method" }
+func (s *trailerStream) SetHeader(metadata.MD) error  { return nil }
+func (s *trailerStream) SendHeader(metadata.MD) error { return nil }
+func (s *trailerStream) SetTrailer(md metadata.MD) error {
+	s.trailer = metadata.Join(s.trailer, md)
+	return nil
+}
+
+// trailerServerStream records trailers set on a streaming RPC.
+type trailerServerStream struct {
+	fakeServerStream
+	trailer metadata.MD
+}
+
+func (s *trailerServerStream) SetTrailer(md metadata.MD) { s.trailer = metadata.Join(s.trailer, md) }
+
+func TestDrainTrailers(t *testing.T) {
+	g := New(nil)
+	g.config.GRPCDrainTrailers = true
+	g.config.GRPCDrainUnavailable = true
+	info := &grpc.UnaryServerInfo{FullMethod: "/svc/Method"}
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "ok", nil }
+
+	sts := &trailerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), sts)
+	if _, err := g.grpcUnaryInterceptor(ctx, nil, info, ok); err != nil || sts.trailer != nil {
+		t.Fatalf("expected no trailer before drain, got %v %v", sts.trailer, err)
+	}
+
+	g.beginDrain(time.Now().Add(time.Minute))
+
+	if _, err := g.grpcUnaryInterceptor(ctx, nil, info, ok); err != nil {
+		t.Fatal(err)
+	}
+	if got := sts.trailer.Get("grpc-drain"); len(got) != 1 || got[0] != "true" {
+		t.Fatalf("expected grpc-drain trailer, got %v", sts.trailer)
+	}
+
+	// A stream cut short by drain becomes UNAVAILABLE with retry pushback
+	ss := &trailerServerStream{}
+	err := g.grpcStreamInterceptor(nil, ss, &grpc.StreamServerInfo{FullMethod: "/svc/Watch"},
+		func(srv interface{}, stream grpc.ServerStream) error {
+			e := stream.Context().Value(requestEntryKey{}).(*requestEntry)
+			e.cancel(ErrDrainDeadlineExceeded)
+			return status.FromContextError(stream.Context().Err()).Err()
+		})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable, got %v", err)
+	}
+	if got := ss.trailer.Get("grpc-retry-pushback-ms"); len(got) != 1 || got[0] != "0" {
+		t.Fatalf("expected retry pushback trailer, got %v", ss.trailer)
+	}
+}
@@ -0,0 +1,45 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// wrapH2C serves handler over cleartext HTTP/2 (h2c) in addition to HTTP/1.
+// The HTTP/2 connections are registered so drain can send GOAWAY on them.
+func (g *Graceful) wrapH2C(handler http.Handler) http.Handler {
+	h2s := &http2.Server{}
+
+	// http2.ConfigureServer registers the connection-wide graceful shutdown
+	// (GOAWAY) as an OnShutdown hook; a listener-less http.Server lets us fire
+	// it at drain start, independent of the real server's Shutdown.
+	trigger := &http.Server{}
+	if err := http2.ConfigureServer(trigger, h2s); err != nil {
+		g.logger.Printf("h2c setup error: %v", err)
+		return handler
+	}
+
+	g.h2.mu.Lock()
+	g.h2.triggers = append(g.h2.triggers, trigger)
+	g.h2.mu.Unlock()
+
+	return h2c.NewHandler(handler, h2s)
+}
+
+// goAwayH2C sends GOAWAY on every active h2c connection so clients stop
+// opening new streams while existing streams finish.
+func (g *Graceful) goAwayH2C() {
+	g.h2.mu.Lock()
+	triggers := g.h2.triggers
+	g.h2.mu.Unlock()
+
+	for _, trigger := range triggers {
+		_ = trigger.Shutdown(context.Background())
+	}
+	if len(triggers) > 0 {
+		g.logger.Printf("Sent GOAWAY to HTTP/2 (h2c) connections")
+	}
+}
@@ -0,0 +1,65 @@
+package gracewrap
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+func TestH2CGoAwayOnDrain(t *testing.T) {
+	g := New(nil)
+	g.config.EnableH2C = true
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})}
+	if err := g.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatalf("wrap: %v", err)
+	}
+	defer g.Shutdown()
+
+	var dials atomic.Int32
+	client := &http.Client{Transport: &http2.Transport{
+		AllowHTTP: true,
+		DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+			dials.Add(1)
+			return net.Dial(network, addr)
+		},
+	}}
+	get := func() string {
+		resp, err := client.Get("http://" + ln.Addr().String())
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		defer resp.Body.Close()
+		return resp.Proto
+	}
+
+	if proto := get(); proto != "HTTP/2.0" {
+		t.Fatalf("expected HTTP/2.0, got %s", proto)
+	}
+	get()
+	if dials.Load() != 1 {
+		t.Fatalf("expected connection reuse before drain, got %d dials", dials.Load())
+	}
+
+	g.goAwayH2C()
+	time.Sleep(50 * time.Millisecond)
+
+	get()
+	if dials.Load() != 2 {
+		t.Fatalf("expected a new connection after GOAWAY, got %d dials", dials.Load())
+	}
+}
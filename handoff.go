@@ -0,0 +1,109 @@
+package gracewrap
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Session is a long-lived connection (websocket, stream) whose
+// application-level state can be handed off to another pod at drain.
+type Session interface {
+	// Snapshot serializes the session state for resumption elsewhere.
+	Snapshot() ([]byte, error)
+	// NotifyResume tells the client the token to present when it reconnects.
+	NotifyResume(token string) error
+}
+
+// SessionStore persists handed-off session state, typically in a shared
+// store (Redis, a database) reachable from the replacement pod.
+type SessionStore interface {
+	Save(ctx context.Context, token string, state []byte) error
+	Load(ctx context.Context, token string) ([]byte, error)
+}
+
+// errNoSessionStore is returned when session resumption is used without a store.
+var errNoSessionStore = errors.New("gracewrap: no SessionStore configured")
+
+// RegisterSession registers a session for handoff at drain.
+// Call the returned func when the session ends normally.
+func (g *Graceful) RegisterSession(s Session) (unregister func()) {
+	g.sessions.mu.Lock()
+	defer g.sessions.mu.Unlock()
+	if g.sessions.m == nil {
+		g.sessions.m = make(map[uint64]Session)
+	}
+	g.sessions.next++
+	id := g.sessions.next
+	g.sessions.m[id] = s
+
+	return func() {
+		g.sessions.mu.Lock()
+		delete(g.sessions.m, id)
+		g.sessions.mu.Unlock()
+	}
+}
+
+// ResumeSession loads the state handed off under token by a draining pod.
+func (g *Graceful) ResumeSession(ctx context.Context, token string) ([]byte, error) {
+	if g.config.SessionStore == nil {
+		return nil, errNoSessionStore
+	}
+	return g.config.SessionStore.Load(ctx, token)
+}
+
+// handoffSessions snapshots every registered session into the SessionStore
+// and tells its client the resume token.
+func (g *Graceful) handoffSessions(deadline time.Time) {
+	if g.config.SessionStore == nil {
+		return
+	}
+
+	g.sessions.mu.Lock()
+	sessions := make([]Session, 0, len(g.sessions.m))
+	for _, s := range g.sessions.m {
+		sessions = append(sessions, s)
+	}
+	g.sessions.mu.Unlock()
+
+	if len(sessions) == 0 {
+		return
+	}
+	g.logger.Printf("Handing off %d session(s)", len(sessions))
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+
+	for _, s := range sessions {
+		if err := g.handoffSession(ctx, s); err != nil {
+			g.logger.Printf("Session handoff error: %v", err)
+		}
+	}
+}
+
+// handoffSession hands off a single session.
+func (g *Graceful) handoffSession(ctx context.Context, s Session) error {
+	state, err := s.Snapshot()
+	if err != nil {
+		return err
+	}
+	token, err := newResumeToken()
+	if err != nil {
+		return err
+	}
+	if err := g.config.SessionStore.Save(ctx, token, state); err != nil {
+		return err
+	}
+	return s.NotifyResume(token)
+}
+
+// newResumeToken returns a random opaque resume token.
+func newResumeToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
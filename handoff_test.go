@@ -0,0 +1,62 @@
+package gracewrap
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+type memSessionStore struct {
+	mu sync.Mutex
+	m  map[string][]byte
+}
+
+func (s *memSessionStore) Save(ctx context.Context, token string, state []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[token] = state
+	return nil
+}
+
+func (s *memSessionStore) Load(ctx context.Context, token string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.m[token], nil
+}
+
+type testSession struct {
+	state string
+	token string
+}
+
+func (s *testSession) Snapshot() ([]byte, error)       { return []byte(s.state), nil }
+func (s *testSession) NotifyResume(token string) error { s.token = token; return nil }
+
+func TestSessionHandoffAtDrain(t *testing.T) {
+	store := &memSessionStore{m: map[string][]byte{}}
+	cfg := DefaultConfig()
+	cfg.LoadBalancerDelay = 0
+	cfg.HardStopTimeout = 0
+	cfg.SessionStore = store
+	g := New(&cfg)
+
+	active := &testSession{state: "cursor=42"}
+	ended := &testSession{state: "gone"}
+	g.RegisterSession(active)
+	g.RegisterSession(ended)()
+
+	g.Shutdown()
+
+	if active.token == "" {
+		t.Fatalf("expected resume token for active session")
+	}
+	if ended.token != "" {
+		t.Fatalf("unregistered session should not be handed off")
+	}
+
+	next := New(&cfg)
+	state, err := next.ResumeSession(context.Background(), active.token)
+	if err != nil || string(state) != "cursor=42" {
+		t.Fatalf("resume: got %q, %v", state, err)
+	}
+}
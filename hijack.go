@@ -0,0 +1,112 @@
+package gracewrap
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// hijackWriter keeps a request in flight after its connection is hijacked
+// (e.g. upgraded to a WebSocket) until the hijacked connection is closed.
+type hijackWriter struct {
+	http.ResponseWriter
+	done func()
+
+	mu       sync.Mutex
+	hijacked bool
+}
+
+// Hijack implements http.Hijacker, handing off request accounting to the connection.
+func (hw *hijackWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := hw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	hw.mu.Lock()
+	hw.hijacked = true
+	hw.mu.Unlock()
+	return &hijackedConn{Conn: conn, done: hw.done}, rw, nil
+}
+
+// writer returns the ResponseWriter handed to the handler: hw itself, or
+// hw with http.Flusher when the underlying writer supports flushing, so
+// capability checks see what the connection can actually do.
+func (hw *hijackWriter) writer() http.ResponseWriter {
+	if f, ok := hw.ResponseWriter.(http.Flusher); ok {
+		return flushingWriter{hijackWriter: hw, f: f}
+	}
+	return hw
+}
+
+// flushingWriter is a hijackWriter over a writer that can flush.
+type flushingWriter struct {
+	*hijackWriter
+	f http.Flusher
+}
+
+// Flush implements http.Flusher.
+func (fw flushingWriter) Flush() {
+	fw.f.Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (hw *hijackWriter) Unwrap() http.ResponseWriter {
+	return hw.ResponseWriter
+}
+
+// finish ends request accounting unless the connection was hijacked.
+func (hw *hijackWriter) finish() {
+	hw.mu.Lock()
+	hijacked := hw.hijacked
+	hw.mu.Unlock()
+	if !hijacked {
+		hw.done()
+	}
+}
+
+// hijackedConn ends request accounting when the hijacked connection closes.
+type hijackedConn struct {
+	net.Conn
+	done func()
+	once sync.Once
+}
+
+// Close implements net.Conn.
+func (c *hijackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.done)
+	return err
+}
+
+// OnDrain registers fn to run when drain starts for the request carried by
+// ctx, typically to close a hijacked connection (WebSocket) cleanly. fn runs
+// after the load balancer delay, before servers are shut down. It is a no-op
+// outside a tracked request.
+func OnDrain(ctx context.Context, fn func()) {
+	e, ok := ctx.Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.onDrain = append(e.onDrain, fn)
+	e.mu.Unlock()
+}
+
+// runDrainCallbacks invokes the OnDrain callbacks of every in-flight request.
+func (g *Graceful) runDrainCallbacks() {
+	for _, e := range g.activeRequests() {
+		e.mu.Lock()
+		fns := e.onDrain
+		e.onDrain = nil
+		e.mu.Unlock()
+		for _, fn := range fns {
+			go fn()
+		}
+	}
+}
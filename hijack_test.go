@@ -0,0 +1,98 @@
+package gracewrap
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHijackedConnectionsStayInflightAndCloseAtDrain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 2 * time.Second
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	hijacked := make(chan struct{})
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		OnDrain(r.Context(), func() { _ = conn.Close() })
+		close(hijacked)
+		// The handler returns; the "websocket" lives on
+	})}
+	if err := g.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatal(err)
+	}
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_, _ = conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: x\r\n\r\n"))
+	<-hijacked
+	time.Sleep(10 * time.Millisecond)
+
+	if n := g.inflightCount(); n != 1 {
+		t.Fatalf("expected hijacked connection to count as in flight, got %d", n)
+	}
+
+	start := time.Now()
+	g.Shutdown()
+	if time.Since(start) > time.Second {
+		t.Fatalf("drain waited for the deadline instead of closing the hijacked conn")
+	}
+	if n := g.inflightCount(); n != 0 {
+		t.Fatalf("expected no in-flight requests after drain, got %d", n)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := bufio.NewReader(conn).ReadByte(); err == nil {
+		t.Fatal("expected client connection to be closed")
+	}
+}
+
+// plainWriter is a ResponseWriter without optional interfaces.
+type plainWriter struct {
+	header http.Header
+}
+
+func (w *plainWriter) Header() http.Header         { return w.header }
+func (w *plainWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *plainWriter) WriteHeader(int)             {}
+
+func TestFlusherOnlyWhenSupported(t *testing.T) {
+	g := New(nil)
+
+	var canFlush bool
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var f http.Flusher
+		f, canFlush = w.(http.Flusher)
+		if canFlush {
+			f.Flush()
+		}
+		if _, ok := w.(http.Hijacker); !ok {
+			t.Error("expected the writer to stay a Hijacker")
+		}
+	}))
+
+	h.ServeHTTP(&plainWriter{header: http.Header{}}, httptest.NewRequest(http.MethodGet, "/", nil))
+	if canFlush {
+		t.Fatal("expected no http.Flusher over a writer that cannot flush")
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !canFlush || !rec.Flushed {
+		t.Fatal("expected Flush to reach the underlying writer")
+	}
+}
@@ -0,0 +1,57 @@
+package gracewrap
+
+import (
+	"context"
+	"time"
+)
+
+// NodeHTTP3 groups every tracked HTTP/3 server in the shutdown order.
+const NodeHTTP3 = "http3"
+
+// HTTP3Server is the subset of quic-go's *http3.Server used by WrapHTTP3.
+// Keeping it an interface avoids a QUIC dependency for users who don't need it.
+type HTTP3Server interface {
+	ListenAndServe() error
+	CloseGracefully(timeout time.Duration) error
+	Close() error
+}
+
+// WrapHTTP3 starts an HTTP/3 (QUIC) server and tracks it for graceful shutdown.
+// The server's handler is not reachable through HTTP3Server, so install
+// tracking yourself before calling WrapHTTP3:
+//
+//	srv := &http3.Server{Addr: ":443", TLSConfig: tlsConf, Handler: g.Chain(mux, gracewrap.Outermost)}
+//	g.WrapHTTP3(srv)
+//
+// At drain the server is closed gracefully within the drain deadline and then
+// closed outright.
+func (g *Graceful) WrapHTTP3(server HTTP3Server) error {
+	info := g.addServer(NodeHTTP3, server)
+	go func() {
+		g.logger.Printf("HTTP/3 server %q starting", info.Name)
+		g.serveFailed(info, nil, server.ListenAndServe())
+	}()
+
+	g.http3Servers = append(g.http3Servers, server)
+	return nil
+}
+
+// stopHTTP3 closes an HTTP/3 server gracefully, then forcibly, returning the
+// first error.
+func (g *Graceful) stopHTTP3(ctx context.Context, srv HTTP3Server) error {
+	name := g.serverName(srv)
+	timeout := time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	graceErr := srv.CloseGracefully(timeout)
+	if graceErr != nil {
+		g.logger.Printf("HTTP/3 server %q graceful close error: %v", name, graceErr)
+	}
+	if err := srv.Close(); err != nil {
+		g.logger.Printf("HTTP/3 server %q close error: %v", name, err)
+		return err
+	}
+	g.logger.Printf("HTTP/3 server %q shutdown completed", name)
+	return graceErr
+}
@@ -0,0 +1,66 @@
+package gracewrap
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeHTTP3Server struct {
+	stop            chan struct{}
+	gracefulTimeout atomic.Int64
+	closed          atomic.Bool
+}
+
+func (f *fakeHTTP3Server) ListenAndServe() error {
+	<-f.stop
+	return errors.New("server closed")
+}
+
+func (f *fakeHTTP3Server) CloseGracefully(timeout time.Duration) error {
+	f.gracefulTimeout.Store(int64(timeout))
+	return nil
+}
+
+func (f *fakeHTTP3Server) Close() error {
+	if f.closed.CompareAndSwap(false, true) {
+		close(f.stop)
+	}
+	return nil
+}
+
+func TestWrapHTTP3ClosesWithinDrain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 2 * time.Second
+
+	srv := &fakeHTTP3Server{stop: make(chan struct{})}
+	if err := g.WrapHTTP3(srv); err != nil {
+		t.Fatal(err)
+	}
+
+	// Tracking via Chain works the same for HTTP/3 handlers
+	var during int64
+	h := g.Chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.inflight.mu.Lock()
+		during = g.inflight.n
+		g.inflight.mu.Unlock()
+	}), Outermost)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if during != 1 {
+		t.Fatalf("expected request to be tracked, got %d", during)
+	}
+
+	g.Shutdown()
+
+	if !srv.closed.Load() {
+		t.Fatal("expected HTTP/3 server to be closed")
+	}
+	if d := time.Duration(srv.gracefulTimeout.Load()); d <= 0 || d > 2*time.Second {
+		t.Fatalf("expected graceful close bounded by drain timeout, got %v", d)
+	}
+}
@@ -0,0 +1,299 @@
+package gracewrap
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// requestEntry is the tracking entry for a single in-flight request.
+type requestEntry struct {
+	id     uint64
+	kind   string // "http" or "grpc"
+	method string
+	path   string
+	start  time.Time
+	drain  <-chan struct{} // closed when drain starts
+	stop   <-chan struct{} // closed when servers begin stopping
+	cancel context.CancelCauseFunc
+
+	mu      sync.Mutex
+	route   string // route template, see SetRoute
+	labels  map[string]string
+	onDrain []func()
+}
+
+// requestEntryKey is the context key under which the tracking entry is stored.
+type requestEntryKey struct{}
+
+// Annotate attaches a label to the in-flight request carried by ctx.
+// Labels appear in drain-timeout logs and, for keys listed in
+// Config.AnnotationMetricKeys, in the gracewrap_request_annotations_total counter.
+// It is a no-op if ctx does not belong to a request tracked by gracewrap.
+func Annotate(ctx context.Context, key, value string) {
+	e, ok := ctx.Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return
+	}
+	e.mu.Lock()
+	if e.labels == nil {
+		e.labels = make(map[string]string)
+	}
+	e.labels[key] = value
+	e.mu.Unlock()
+}
+
+// Annotations returns a copy of the labels attached to the request carried by ctx.
+func Annotations(ctx context.Context) map[string]string {
+	e, ok := ctx.Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return nil
+	}
+	return e.labelsCopy()
+}
+
+// Draining reports whether drain has started for the service handling the
+// request carried by ctx. It returns false outside a tracked request.
+func Draining(ctx context.Context) bool {
+	select {
+	case <-DrainNotify(ctx):
+		return true
+	default:
+		return false
+	}
+}
+
+// DrainNotify returns a channel that is closed when drain starts, so streaming
+// handlers (SSE, long-poll) can flush a final event and return promptly:
+//
+//	select {
+//	case ev := <-events:
+//		// write ev
+//	case <-gracewrap.DrainNotify(r.Context()):
+//		// write a final event and return
+//	}
+//
+// Outside a tracked request it returns nil, which never fires.
+func DrainNotify(ctx context.Context) <-chan struct{} {
+	e, ok := ctx.Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return nil
+	}
+	return e.drain
+}
+
+// DrainChan returns a channel that is closed when the server handling the
+// streaming RPC (or HTTP request) carried by ctx begins to stop, after the
+// load balancer delay. Long-lived streams should then send a final message
+// or status and return, rather than be severed when the drain deadline
+// forces the server to stop:
+//
+//	for {
+//		select {
+//		case ev := <-events:
+//			if err := stream.Send(ev); err != nil {
+//				return err
+//			}
+//		case <-gracewrap.DrainChan(stream.Context()):
+//			return status.Error(codes.Unavailable, "server shutting down; reconnect")
+//		}
+//	}
+//
+// Unlike DrainNotify it does not fire while the pod may still receive new
+// traffic. Outside a tracked request it returns nil, which never fires.
+func DrainChan(ctx context.Context) <-chan struct{} {
+	e, ok := ctx.Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return nil
+	}
+	return e.stop
+}
+
+// SetRoute records the route template (e.g. "/users/:id") of the request
+// carried by ctx for per-route metrics. Routers that only know the template
+// while routing (gin's FullPath, echo's Path) call it from inside the handler
+// chain; it takes precedence over Config.RouteLabelFunc.
+func SetRoute(ctx context.Context, route string) {
+	e, ok := ctx.Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return
+	}
+	e.mu.Lock()
+	e.route = route
+	e.mu.Unlock()
+}
+
+// observeRoute records per-route metrics for a completed HTTP request, if a
+// route template is known for it.
+func (g *Graceful) observeRoute(r *http.Request) {
+	if g.metrics == nil {
+		return
+	}
+	e, ok := r.Context().Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return
+	}
+	e.mu.Lock()
+	route := e.route
+	e.mu.Unlock()
+	if route == "" && g.config.RouteLabelFunc != nil {
+		route = g.config.RouteLabelFunc(r)
+	}
+	if route == "" {
+		return
+	}
+	g.metrics.observeRoute(r.Method, route, time.Since(e.start))
+}
+
+// labelsCopy returns a copy of the entry's labels.
+func (e *requestEntry) labelsCopy() map[string]string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.labels) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(e.labels))
+	for k, v := range e.labels {
+		out[k] = v
+	}
+	return out
+}
+
+// String formats the entry for log output.
+func (e *requestEntry) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s %s (age %v)", e.kind, e.method, e.path, time.Since(e.start).Round(time.Millisecond))
+	labels := e.labelsCopy()
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%s", k, labels[k])
+	}
+	return b.String()
+}
+
+// RequestInfo describes a tracked request as passed to Config.OnRequestStart
+// and Config.OnRequestEnd.
+type RequestInfo struct {
+	ID       uint64 // unique per process, in arrival order
+	Kind     string // "http" or "grpc"
+	Method   string // HTTP method, or gRPC method kind ("unary"/"stream")
+	Path     string // URL path or full gRPC method
+	Start    time.Time
+	Duration time.Duration     // zero in OnRequestStart
+	Inflight int64             // in-flight count including this request at start, excluding it at end
+	Draining bool              // whether drain had started
+	Labels   map[string]string // see Annotate; nil in OnRequestStart
+}
+
+// info returns the RequestInfo for e.
+func (e *requestEntry) info(g *Graceful, inflight int64, end bool) RequestInfo {
+	ri := RequestInfo{
+		ID:       e.id,
+		Kind:     e.kind,
+		Method:   e.method,
+		Path:     e.path,
+		Start:    e.start,
+		Inflight: inflight,
+		Draining: g.draining(),
+	}
+	if end {
+		ri.Duration = time.Since(e.start)
+		ri.Labels = e.labelsCopy()
+	}
+	return ri
+}
+
+// trackRequest registers a new in-flight request and returns a context carrying
+// its tracking entry. The returned func must be called when the request ends.
+func (g *Graceful) trackRequest(ctx context.Context, kind, method, path string) (context.Context, func()) {
+	e := &requestEntry{
+		kind:   kind,
+		method: method,
+		path:   path,
+		start:  time.Now(),
+		drain:  g.drainStarted(),
+		stop:   g.stoppingStarted(),
+	}
+
+	g.inflight.mu.Lock()
+	g.inflight.nextID++
+	e.id = g.inflight.nextID
+	if g.inflight.active == nil {
+		g.inflight.active = make(map[uint64]*requestEntry)
+	}
+	g.inflight.active[e.id] = e
+	g.inflight.mu.Unlock()
+
+	n := g.incInflight()
+	if fn := g.config.OnRequestStart; fn != nil {
+		fn(e.info(g, n, false))
+	}
+
+	ctx, e.cancel = context.WithCancelCause(context.WithValue(ctx, requestEntryKey{}, e))
+	if g.draining() {
+		g.cancelOverBudget(e, g.drainDeadline())
+	}
+
+	// done may be called both by the handler and by its connection's owner
+	// (see connTracker), so it must be idempotent
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			e.cancel(nil)
+			g.inflight.mu.Lock()
+			delete(g.inflight.active, e.id)
+			g.inflight.mu.Unlock()
+
+			if g.metrics != nil {
+				g.metrics.observeAnnotations(e.labelsCopy(), g.config.AnnotationMetricKeys)
+			}
+
+			n := g.decInflight()
+			if fn := g.config.OnRequestEnd; fn != nil {
+				fn(e.info(g, n, true))
+			}
+		})
+	}
+}
+
+// inflightCount returns the current number of in-flight requests.
+func (g *Graceful) inflightCount() int64 {
+	g.inflight.mu.Lock()
+	defer g.inflight.mu.Unlock()
+	return g.inflight.n
+}
+
+// requestsSeen returns the number of requests tracked since the process started.
+func (g *Graceful) requestsSeen() uint64 {
+	g.inflight.mu.Lock()
+	defer g.inflight.mu.Unlock()
+	return g.inflight.nextID
+}
+
+// activeRequests returns the tracked in-flight requests, oldest first.
+func (g *Graceful) activeRequests() []*requestEntry {
+	g.inflight.mu.Lock()
+	out := make([]*requestEntry, 0, len(g.inflight.active))
+	for _, e := range g.inflight.active {
+		out = append(out, e)
+	}
+	g.inflight.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool { return out[i].start.Before(out[j].start) })
+	return out
+}
+
+// logActiveRequests logs every request still in flight.
+func (g *Graceful) logActiveRequests() {
+	for _, e := range g.activeRequests() {
+		g.logger.Printf("  still in flight: %s", e)
+	}
+}
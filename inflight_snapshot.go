@@ -0,0 +1,82 @@
+package gracewrap
+
+import (
+	"time"
+)
+
+// slowRequestsLogged is how many of the oldest in-flight requests are logged
+// at each drain checkpoint.
+const slowRequestsLogged = 5
+
+// drainCheckpoints are the fractions of the drain budget at which the oldest
+// in-flight requests are logged.
+var drainCheckpoints = []float64{0.5, 0.9}
+
+// InflightRequest describes a request still being served.
+type InflightRequest struct {
+	Kind   string            `json:"kind"`
+	Method string            `json:"method"`
+	Path   string            `json:"path"`
+	Start  time.Time         `json:"start"`
+	Age    time.Duration     `json:"age"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// InflightSnapshot returns the requests currently in flight, oldest first,
+// so operators can see which endpoints are blocking shutdown.
+func (g *Graceful) InflightSnapshot() []InflightRequest {
+	now := time.Now()
+	active := g.activeRequests()
+	out := make([]InflightRequest, 0, len(active))
+	for _, e := range active {
+		out = append(out, InflightRequest{
+			Kind:   e.kind,
+			Method: e.method,
+			Path:   e.path,
+			Start:  e.start,
+			Age:    now.Sub(e.start),
+			Labels: e.labelsCopy(),
+		})
+	}
+	return out
+}
+
+// logSlowRequests logs the oldest in-flight requests once 50% and 90% of the
+// drain budget have elapsed, until stop is closed.
+func (g *Graceful) logSlowRequests(stop <-chan struct{}) {
+	start := g.drainStartedAt()
+	for _, frac := range drainCheckpoints {
+	checkpoint:
+		for {
+			// The budget grows or shrinks when commitDrain moves the deadline
+			moved, deadline := g.drainWindow()
+			budget := deadline.Sub(start)
+			if budget <= 0 {
+				return
+			}
+			at := start.Add(time.Duration(float64(budget) * frac))
+			timer := time.NewTimer(time.Until(at))
+			select {
+			case <-timer.C:
+				break checkpoint
+			case <-moved:
+				timer.Stop()
+			case <-stop:
+				timer.Stop()
+				return
+			}
+		}
+
+		active := g.activeRequests()
+		if len(active) == 0 {
+			continue
+		}
+		g.logger.Printf("%.0f%% of drain budget elapsed; %d request(s) in flight, oldest:", frac*100, len(active))
+		if len(active) > slowRequestsLogged {
+			active = active[:slowRequestsLogged]
+		}
+		for _, e := range active {
+			g.logger.Printf("  still in flight: %s", e)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package gracewrap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInflightSnapshotOldestFirst(t *testing.T) {
+	g := newTestGraceful(t)
+
+	ctx, doneOld := g.trackRequest(context.Background(), "http", "GET", "/old")
+	defer doneOld()
+	Annotate(ctx, "tenant", "acme")
+	time.Sleep(5 * time.Millisecond)
+	_, doneNew := g.trackRequest(context.Background(), "grpc", "", "/pkg.Svc/Call")
+	defer doneNew()
+
+	snap := g.InflightSnapshot()
+	if len(snap) != 2 {
+		t.Fatalf("expected 2 requests, got %+v", snap)
+	}
+	if snap[0].Path != "/old" || snap[1].Path != "/pkg.Svc/Call" {
+		t.Fatalf("expected oldest first, got %+v", snap)
+	}
+	if snap[0].Method != "GET" || snap[0].Kind != "http" || snap[0].Labels["tenant"] != "acme" {
+		t.Fatalf("unexpected entry %+v", snap[0])
+	}
+	if snap[0].Age < snap[1].Age || snap[0].Age < 5*time.Millisecond {
+		t.Fatalf("unexpected ages %v, %v", snap[0].Age, snap[1].Age)
+	}
+
+	doneOld()
+	if snap := g.InflightSnapshot(); len(snap) != 1 || snap[0].Path != "/pkg.Svc/Call" {
+		t.Fatalf("expected finished request to be dropped, got %+v", snap)
+	}
+}
+
+func TestSlowRequestsLoggedAtCheckpoints(t *testing.T) {
+	var logs bytes.Buffer
+	g := New(&Config{Logger: log.New(&logs, "", 0)})
+
+	for i := 0; i < slowRequestsLogged+2; i++ {
+		_, done := g.trackRequest(context.Background(), "http", "GET", fmt.Sprintf("/req%d", i))
+		defer done()
+		time.Sleep(time.Millisecond)
+	}
+
+	g.beginDrain(time.Now().Add(100 * time.Millisecond))
+	start := time.Now()
+	g.logSlowRequests(make(chan struct{}))
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("checkpoints fired too early (%v)", elapsed)
+	}
+
+	out := logs.String()
+	for _, want := range []string{"50% of drain budget elapsed", "90% of drain budget elapsed", "/req0", fmt.Sprintf("/req%d", slowRequestsLogged-1)} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in logs, got %q", want, out)
+		}
+	}
+	if strings.Contains(out, fmt.Sprintf("/req%d", slowRequestsLogged)) {
+		t.Fatalf("expected only the %d oldest requests, got %q", slowRequestsLogged, out)
+	}
+}
+
+func TestSlowRequestsNotLoggedAfterStop(t *testing.T) {
+	var logs bytes.Buffer
+	g := New(&Config{Logger: log.New(&logs, "", 0)})
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/slow")
+	defer done()
+
+	g.beginDrain(time.Now().Add(time.Hour))
+	stop := make(chan struct{})
+	close(stop)
+	g.logSlowRequests(stop)
+	if logs.Len() != 0 {
+		t.Fatalf("expected no logs after stop, got %q", logs.String())
+	}
+}
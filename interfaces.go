@@ -0,0 +1,45 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// Wrapper starts servers under graceful shutdown management.
+type Wrapper interface {
+	WrapHTTP(server *http.Server) error
+	WrapHTTPTLS(server *http.Server, certFile, keyFile string) error
+	WrapHTTPWithListener(server *http.Server, listener net.Listener) error
+	WrapGRPC(server *grpc.Server, listener net.Listener) error
+	NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server
+	ServeGRPC(addr string, opts ...grpc.ServerOption) (*grpc.Server, net.Listener, error)
+}
+
+// Drainer controls the shutdown lifecycle.
+type Drainer interface {
+	Wait(ctx context.Context) error
+	Shutdown()
+	Fail(err error)
+	OnShutdown(name string, fn func(ctx context.Context) error)
+}
+
+// HealthReporter exposes readiness and liveness.
+type HealthReporter interface {
+	Ready() bool
+	HealthHandler() http.Handler
+	LivenessHandler() http.Handler
+}
+
+// Lifecycle is everything a service typically needs from gracewrap. Accept it
+// (or one of its parts) instead of *Graceful to mock gracewrap in tests or to
+// swap in another implementation.
+type Lifecycle interface {
+	Wrapper
+	Drainer
+	HealthReporter
+}
+
+var _ Lifecycle = (*Graceful)(nil)
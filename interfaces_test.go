@@ -0,0 +1,35 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// fakeDrainer is what a downstream test would write instead of running servers.
+type fakeDrainer struct{ hooks []string }
+
+func (f *fakeDrainer) Wait(ctx context.Context) error { return nil }
+func (f *fakeDrainer) Shutdown()                      {}
+func (f *fakeDrainer) Fail(err error)                 {}
+func (f *fakeDrainer) OnShutdown(name string, fn func(ctx context.Context) error) {
+	f.hooks = append(f.hooks, name)
+}
+
+func TestInterfacesAcceptGracefulAndFakes(t *testing.T) {
+	register := func(d Drainer) { d.OnShutdown("db", func(context.Context) error { return nil }) }
+
+	fake := &fakeDrainer{}
+	register(fake)
+	if len(fake.hooks) != 1 {
+		t.Fatalf("expected hook on fake, got %v", fake.hooks)
+	}
+
+	var hr HealthReporter = New(nil)
+	rr := httptest.NewRecorder()
+	hr.HealthHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/health/ready", nil))
+	if !hr.Ready() || rr.Code != http.StatusOK {
+		t.Fatalf("expected ready, got %d", rr.Code)
+	}
+}
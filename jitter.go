@@ -0,0 +1,24 @@
+package gracewrap
+
+import (
+	"hash/fnv"
+	"os"
+	"time"
+)
+
+// jitter returns a fraction of max that is stable for this pod and purpose,
+// so replicas terminated together (e.g. by a node drain) spread their drain
+// phases instead of closing connections in synchronized waves. The pod is
+// identified by POD_NAME, falling back to the hostname.
+func jitter(max time.Duration, purpose string) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	pod := os.Getenv("POD_NAME")
+	if pod == "" {
+		pod, _ = os.Hostname()
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(pod + "/" + purpose))
+	return time.Duration(h.Sum64() % uint64(max))
+}
@@ -0,0 +1,30 @@
+package gracewrap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestJitterStablePerPod(t *testing.T) {
+	if jitter(0, "lb") != 0 {
+		t.Fatal("expected no jitter when disabled")
+	}
+
+	t.Setenv("POD_NAME", "api-7d9f-abcde")
+	a := jitter(time.Second, "lb")
+	if a != jitter(time.Second, "lb") {
+		t.Fatal("expected jitter to be stable for a pod")
+	}
+	if a < 0 || a >= time.Second {
+		t.Fatalf("jitter %v out of range", a)
+	}
+
+	distinct := map[time.Duration]bool{}
+	for _, pod := range []string{"api-0", "api-1", "api-2", "api-3"} {
+		t.Setenv("POD_NAME", pod)
+		distinct[jitter(time.Second, "lb")] = true
+	}
+	if len(distinct) < 2 {
+		t.Fatal("expected replicas to get different jitter")
+	}
+}
@@ -0,0 +1,36 @@
+package gracewrap
+
+import (
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestKeepAlivesDisabledWhenDrainStarts(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 200 * time.Millisecond
+	g.config.HardStopTimeout = 0
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	if err := g.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatal(err)
+	}
+
+	go g.Shutdown()
+	time.Sleep(50 * time.Millisecond)
+
+	// During the load balancer delay the server still answers, but closes the connection
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if !resp.Close {
+		t.Fatal("expected Connection: close while draining")
+	}
+}
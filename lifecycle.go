@@ -0,0 +1,234 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"time"
+)
+
+// beginDrain marks the start of the drain phase: it closes the drain channel
+// and records the expected drain deadline, which commitDrain replaces with the
+// one servers are actually given.
+func (g *Graceful) beginDrain(deadline time.Time) {
+	ready := g.Ready()
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	g.drain.wasReady = ready
+	g.drain.started = time.Now()
+	g.drain.deadline = deadline
+	g.drain.cancel = make(chan struct{})
+	if g.drain.moved != nil {
+		select {
+		case <-g.drain.moved:
+		default:
+			close(g.drain.moved)
+		}
+	}
+	g.drain.moved = make(chan struct{})
+	g.drain.committed = false
+	if g.drain.ctxCancel != nil {
+		g.drain.ctxCancel()
+	}
+	select {
+	case <-g.drain.ch:
+	default:
+		close(g.drain.ch)
+	}
+}
+
+// drainStarted returns a channel that is closed once drain begins.
+func (g *Graceful) drainStarted() <-chan struct{} {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.ch
+}
+
+// beginStopping records that servers are about to stop, once the load
+// balancer delay is over.
+func (g *Graceful) beginStopping() {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	select {
+	case <-g.drain.stopping:
+	default:
+		close(g.drain.stopping)
+	}
+}
+
+// stoppingStarted returns a channel that is closed once servers begin stopping.
+func (g *Graceful) stoppingStarted() <-chan struct{} {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.stopping
+}
+
+// draining reports whether drain has started.
+func (g *Graceful) draining() bool {
+	select {
+	case <-g.drainStarted():
+		return true
+	default:
+		return false
+	}
+}
+
+// drainStartedAt returns when drain began, or the zero time if it has not.
+func (g *Graceful) drainStartedAt() time.Time {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.started
+}
+
+// drainDeadline returns the drain deadline, or the zero time if drain has not started.
+func (g *Graceful) drainDeadline() time.Time {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.deadline
+}
+
+// drainWindow returns the drain deadline and a channel closed once it moves
+// (see commitDrain) or the drain is cancelled, read together. The deadline is
+// zero if the drain has already been cancelled.
+func (g *Graceful) drainWindow() (<-chan struct{}, time.Time) {
+	g.drain.mu.Lock()
+	defer g.drain.mu.Unlock()
+	return g.drain.moved, g.drain.deadline
+}
+
+// awaitDrainDeadline blocks until extra has elapsed past the drain deadline
+// and returns true, or returns false once stop is closed. It follows the
+// deadline when commitDrain moves it, and waits for the next drain when one
+// is cancelled.
+func (g *Graceful) awaitDrainDeadline(extra time.Duration, stop <-chan struct{}) bool {
+	for {
+		select {
+		case <-g.drainStarted():
+		case <-stop:
+			return false
+		}
+		moved, deadline := g.drainWindow()
+		if deadline.IsZero() {
+			// Cancelled meanwhile; wait for the next drain
+			continue
+		}
+		timer := time.NewTimer(time.Until(deadline.Add(extra)))
+		select {
+		case <-timer.C:
+			return true
+		case <-moved:
+			timer.Stop()
+		case <-stop:
+			timer.Stop()
+			return false
+		}
+	}
+}
+
+// untilDrainDeadline returns a context that is cancelled once the drain
+// deadline passes (or when the returned cancel func is called).
+func (g *Graceful) untilDrainDeadline(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	go func() {
+		if g.awaitDrainDeadline(0, ctx.Done()) {
+			cancel()
+		}
+	}()
+	return ctx, cancel
+}
+
+// setBaseContext derives the server's request contexts from one that is
+// cancelled at the drain deadline, so handlers blocked on a database call get
+// a real cancellation signal instead of being abandoned at hard stop. A
+// BaseContext already set on the server is kept as the parent.
+func (g *Graceful) setBaseContext(server *http.Server) {
+	parent := server.BaseContext
+	server.BaseContext = func(l net.Listener) context.Context {
+		base := context.Background()
+		if parent != nil {
+			base = parent(l)
+		}
+		ctx, cancel := g.untilDrainDeadline(base)
+		g.baseContexts.mu.Lock()
+		if g.baseContexts.cancels == nil {
+			g.baseContexts.cancels = make(map[*http.Server][]context.CancelFunc)
+		}
+		g.baseContexts.cancels[server] = append(g.baseContexts.cancels[server], cancel)
+		g.baseContexts.mu.Unlock()
+		return ctx
+	}
+}
+
+// releaseBaseContexts cancels the base contexts of server once it has stopped.
+func (g *Graceful) releaseBaseContexts(server *http.Server) {
+	g.baseContexts.mu.Lock()
+	cancels := g.baseContexts.cancels[server]
+	delete(g.baseContexts.cancels, server)
+	g.baseContexts.mu.Unlock()
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// Fail records err as the shutdown cause and starts graceful shutdown.
+// Wait returns err (joined with any shutdown error) once shutdown completes.
+// Only the first cause is kept.
+func (g *Graceful) Fail(err error) {
+	g.stop.once.Do(func() {
+		g.stop.err = err
+		close(g.stop.ch)
+	})
+	go g.shutdown()
+}
+
+// stopRequested returns a channel closed once Fail has been called.
+func (g *Graceful) stopRequested() <-chan struct{} {
+	return g.stop.ch
+}
+
+// cutShort ends the waits of a shutdown in progress (see ShutdownContext and
+// ForceShutdown).
+func (g *Graceful) cutShort() {
+	g.cut.once.Do(func() {
+		close(g.cut.ch)
+		g.logger.Printf("Cutting remaining shutdown waits short")
+	})
+}
+
+// wasCut reports whether the shutdown has been cut short.
+func (g *Graceful) wasCut() bool {
+	select {
+	case <-g.cut.ch:
+		return true
+	default:
+		return false
+	}
+}
+
+// pause sleeps for d, returning early if the shutdown is cut short.
+func (g *Graceful) pause(d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-g.cut.ch:
+	}
+}
+
+// cutDeadline returns deadline, or now once the shutdown has been cut short.
+func (g *Graceful) cutDeadline(deadline time.Time) time.Time {
+	if g.wasCut() {
+		return time.Now()
+	}
+	return deadline
+}
+
+// failure returns the cause given to Fail, or nil if Fail was not called.
+func (g *Graceful) failure() error {
+	select {
+	case <-g.stop.ch:
+		return g.stop.err
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+package gracewrap
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// shutdownCompleted reports whether shutdown has completed.
+func (g *Graceful) shutdownCompleted() bool {
+	select {
+	case <-g.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// keepLivenessProbes rebinds the address of server once its listener closes
+// at shutdown and keeps answering Config.LivenessPath there until shutdown
+// completes. Other paths get 503.
+func (g *Graceful) keepLivenessProbes(server *http.Server) {
+	addr := g.serverAddr(server)
+	if addr == nil {
+		return
+	}
+
+	// Shutdown closes the listener right away; retry until the port is free
+	var ln net.Listener
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(5 * time.Millisecond) {
+		var err error
+		if ln, err = net.Listen(addr.Network(), addr.String()); err == nil {
+			break
+		}
+		if time.Now().After(deadline) || g.shutdownCompleted() {
+			g.logger.Printf("Cannot keep liveness probes on %s: %v", addr, err)
+			return
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(g.config.LivenessPath, g.LivenessHandler())
+	mux.Handle("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "draining", http.StatusServiceUnavailable)
+	}))
+	probe := &http.Server{Handler: mux, TLSConfig: server.TLSConfig}
+
+	g.probes.mu.Lock()
+	if g.shutdownCompleted() {
+		g.probes.mu.Unlock()
+		ln.Close()
+		return
+	}
+	g.probes.list = append(g.probes.list, probe)
+	g.probes.mu.Unlock()
+
+	if hasTLSCertificates(server.TLSConfig) {
+		_ = probe.ServeTLS(ln, "", "")
+	} else {
+		_ = probe.Serve(ln)
+	}
+}
+
+// closeProbes stops the liveness probe servers.
+func (g *Graceful) closeProbes() {
+	g.probes.mu.Lock()
+	defer g.probes.mu.Unlock()
+	for _, p := range g.probes.list {
+		_ = p.Close()
+	}
+	g.probes.list = nil
+}
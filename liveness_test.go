@@ -0,0 +1,63 @@
+package gracewrap
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLivenessSurvivesDrainOnSharedPort(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 300 * time.Millisecond
+	g.config.RejectWhileDraining = true
+	g.config.LivenessPath = "/health/live"
+	g.config.LivenessFailAfterShutdown = true
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/health/ready", g.HealthHandler())
+	if err := g.WrapHTTPWithListener(&http.Server{Handler: mux}, ln); err != nil {
+		t.Fatal(err)
+	}
+	url := "http://" + ln.Addr().String() + "/health/live"
+	probe := func() int {
+		client := &http.Client{Timeout: time.Second, Transport: &http.Transport{DisableKeepAlives: true}}
+		resp, err := client.Get(url)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := probe(); code != http.StatusOK {
+		t.Fatalf("expected live before drain, got %d", code)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		g.Shutdown()
+		close(done)
+	}()
+
+	// The server is closed and we are in the hard stop phase: probes still pass
+	time.Sleep(150 * time.Millisecond)
+	if code := probe(); code != http.StatusOK {
+		t.Fatalf("expected liveness to survive the drain, got %d", code)
+	}
+	<-done
+
+	rec := httptest.NewRecorder()
+	g.LivenessHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/health/live", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 once shutdown completed, got %d", rec.Code)
+	}
+	if code := probe(); code != 0 {
+		t.Fatalf("expected probe server to be closed after shutdown, got %d", code)
+	}
+}
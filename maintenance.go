@@ -0,0 +1,62 @@
+package gracewrap
+
+// SetReady sets the readiness reported by HealthHandler and the gRPC health
+// service, e.g. to hold traffic off until caches are warm. Readiness cannot
+// be restored once drain has started or while in maintenance (see
+// EnterMaintenance), so SetReady(true) is ignored then.
+func (g *Graceful) SetReady(ready bool) {
+	if ready && g.draining() {
+		g.logger.Printf("Ignoring SetReady(true) while draining")
+		return
+	}
+	if ready && g.maintenance.Load() {
+		g.logger.Printf("Ignoring SetReady(true) while in maintenance")
+		return
+	}
+	g.setReady(ready)
+}
+
+// EnterMaintenance takes the pod out of rotation without shutting down, so
+// operators can debug it and bring it back with ExitMaintenance: readiness
+// is set to false, keep-alives are disabled (with
+// Config.DisableKeepAlivesOnDrain) and it returns after the load balancer
+// delay, once traffic has stopped being routed here. It returns ErrDraining
+// if drain has started. Wire it to a signal with OnSignal:
+//
+//	g.OnSignal(syscall.SIGUSR1, func() { _ = g.EnterMaintenance() })
+//	g.OnSignal(syscall.SIGUSR2, func() { _ = g.ExitMaintenance() })
+func (g *Graceful) EnterMaintenance() error {
+	if g.draining() {
+		return ErrDraining
+	}
+	if g.maintenance.Swap(true) {
+		return nil
+	}
+	g.setReady(false)
+	if g.config.DisableKeepAlivesOnDrain {
+		g.setKeepAlives(false)
+	}
+	g.logger.Printf("Entering maintenance; health checks will now return 503")
+
+	if d := g.config.LoadBalancerDelay; d > 0 {
+		g.awaitLoadBalancers(d, g.drainStarted())
+	}
+	return nil
+}
+
+// ExitMaintenance puts the pod back in rotation after EnterMaintenance. It
+// returns ErrDraining if drain has started meanwhile.
+func (g *Graceful) ExitMaintenance() error {
+	if g.draining() {
+		return ErrDraining
+	}
+	if !g.maintenance.Swap(false) {
+		return nil
+	}
+	if g.config.DisableKeepAlivesOnDrain {
+		g.setKeepAlives(true)
+	}
+	g.setReady(true)
+	g.logger.Printf("Exited maintenance; marked as ready again")
+	return nil
+}
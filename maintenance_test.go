@@ -0,0 +1,61 @@
+package gracewrap
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMaintenance(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 30 * time.Millisecond
+
+	start := time.Now()
+	if err := g.EnterMaintenance(); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 30*time.Millisecond {
+		t.Fatal("expected EnterMaintenance to wait for the load balancer delay")
+	}
+	if g.Ready() {
+		t.Fatal("expected not ready in maintenance")
+	}
+	if g.draining() {
+		t.Fatal("maintenance must not start draining")
+	}
+	g.SetReady(true)
+	if g.Ready() {
+		t.Fatal("SetReady(true) must not end maintenance")
+	}
+
+	if err := g.ExitMaintenance(); err != nil {
+		t.Fatal(err)
+	}
+	if !g.Ready() {
+		t.Fatal("expected ready after maintenance")
+	}
+}
+
+func TestSetReady(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	g.SetReady(false)
+	if g.Ready() {
+		t.Fatal("expected not ready")
+	}
+	g.SetReady(true)
+	if !g.Ready() {
+		t.Fatal("expected ready")
+	}
+
+	g.Shutdown()
+	g.SetReady(true)
+	if g.Ready() {
+		t.Fatal("expected SetReady(true) to be ignored once draining")
+	}
+	if err := g.EnterMaintenance(); !errors.Is(err, ErrDraining) {
+		t.Fatalf("expected ErrDraining, got %v", err)
+	}
+}
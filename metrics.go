@@ -1,6 +1,7 @@
 package gracewrap
 
 import (
+	"net/http"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -14,6 +15,24 @@ type metrics struct {
 	shutdownDuration  prometheus.Histogram
 	readinessStatus   prometheus.Gauge
 	shutdownsTotal    prometheus.Counter
+	annotationsTotal  *prometheus.CounterVec
+	poolQueueDepth    *prometheus.GaugeVec
+	poolBusyWorkers   *prometheus.GaugeVec
+	poolWorkers       *prometheus.GaugeVec
+	uploadsCutTotal   prometheus.Counter
+	rejectedTotal     *prometheus.CounterVec
+	connections       *prometheus.GaugeVec
+	drainBypassTotal  prometheus.Counter
+	budgetCancelled   prometheus.Counter
+	shedTotal         *prometheus.CounterVec
+	routeRequests     *prometheus.CounterVec
+	routeDuration     *prometheus.HistogramVec
+	drainBudgetUsed   prometheus.Gauge
+	stragglers        *prometheus.CounterVec
+	grpcMethodTotal   *prometheus.CounterVec
+	grpcDuration      *prometheus.HistogramVec
+	serverStop        *prometheus.GaugeVec
+	shutdownStep      *prometheus.HistogramVec
 	registerer        prometheus.Registerer
 	gatherer          prometheus.Gatherer
 }
@@ -63,6 +82,81 @@ func newMetrics(registry prometheus.Registerer) *metrics {
 			Name: "gracewrap_shutdowns_total",
 			Help: "Total number of shutdowns initiated",
 		}),
+		annotationsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gracewrap_request_annotations_total",
+			Help: "Total number of completed requests by annotation key and value",
+		}, []string{"key", "value"}),
+		poolQueueDepth: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gracewrap_worker_pool_queue_depth",
+			Help: "Current number of queued worker pool jobs",
+		}, []string{"pool"}),
+		poolBusyWorkers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gracewrap_worker_pool_busy_workers",
+			Help: "Current number of worker pool workers running a job",
+		}, []string{"pool"}),
+		poolWorkers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gracewrap_worker_pool_workers",
+			Help: "Number of workers in the worker pool",
+		}, []string{"pool"}),
+		uploadsCutTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gracewrap_uploads_cut_total",
+			Help: "Total number of request body uploads cut off by drain",
+		}),
+		rejectedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gracewrap_requests_rejected_total",
+			Help: "Total number of requests rejected while draining",
+		}, []string{"protocol"}),
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gracewrap_http_connections",
+			Help: "Current number of HTTP connections by state (new, active, idle)",
+		}, []string{"state"}),
+		drainBypassTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gracewrap_drain_bypass_requests_total",
+			Help: "Total number of requests served while draining because DrainBypass matched",
+		}),
+		budgetCancelled: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gracewrap_requests_budget_cancelled_total",
+			Help: "Total number of requests cancelled during drain because they could not finish before the deadline",
+		}),
+		shedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gracewrap_requests_shed_total",
+			Help: "Total number of requests shed because the in-flight limit was reached",
+		}, []string{"protocol"}),
+		routeRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gracewrap_http_route_requests_total",
+			Help: "Total number of HTTP requests by method and route template",
+		}, []string{"method", "route"}),
+		routeDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gracewrap_http_request_duration_seconds",
+			Help:    "HTTP request duration by method and route template",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		drainBudgetUsed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gracewrap_drain_budget_used_ratio",
+			Help: "Mean share of DrainTimeout used by recent drains, read from the drain history file at startup",
+		}),
+		stragglers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gracewrap_drain_stragglers_total",
+			Help: "Requests still in flight at the drain deadline by outcome (abandoned, cancelled, finished_late)",
+		}, []string{"outcome"}),
+		grpcMethodTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gracewrap_grpc_method_requests_total",
+			Help: "Total number of gRPC requests by full method and status code",
+		}, []string{"method", "code"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gracewrap_grpc_request_duration_seconds",
+			Help:    "gRPC request duration by full method and status code",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		serverStop: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gracewrap_server_stop_duration_seconds",
+			Help: "How long each server took to stop during the last shutdown, by server name and kind",
+		}, []string{"server", "kind"}),
+		shutdownStep: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gracewrap_shutdown_step_duration_seconds",
+			Help:    "Time taken by each shutdown step (phase, server:<name> or hook:<name>)",
+			Buckets: []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 15, 20, 25, 30, 60},
+		}, []string{"step"}),
 		registerer: reg,
 		gatherer:   gath,
 	}
@@ -75,6 +169,24 @@ func newMetrics(registry prometheus.Registerer) *metrics {
 		m.shutdownDuration,
 		m.readinessStatus,
 		m.shutdownsTotal,
+		m.annotationsTotal,
+		m.poolQueueDepth,
+		m.poolBusyWorkers,
+		m.poolWorkers,
+		m.uploadsCutTotal,
+		m.rejectedTotal,
+		m.connections,
+		m.drainBypassTotal,
+		m.budgetCancelled,
+		m.shedTotal,
+		m.routeRequests,
+		m.routeDuration,
+		m.drainBudgetUsed,
+		m.stragglers,
+		m.grpcMethodTotal,
+		m.grpcDuration,
+		m.serverStop,
+		m.shutdownStep,
 	)
 
 	return m
@@ -113,3 +225,86 @@ func (m *metrics) incShutdowns() {
 func (m *metrics) observeShutdownDuration(duration time.Duration) {
 	m.shutdownDuration.Observe(duration.Seconds())
 }
+
+// observeAnnotations counts a completed request's annotations for the allowed keys
+func (m *metrics) observeAnnotations(labels map[string]string, keys []string) {
+	for _, k := range keys {
+		if v, ok := labels[k]; ok {
+			m.annotationsTotal.WithLabelValues(k, v).Inc()
+		}
+	}
+}
+
+// workerPoolGauges returns the queue depth and busy worker gauges for a pool
+func (m *metrics) workerPoolGauges(pool string, workers int) (depth, busy prometheus.Gauge) {
+	m.poolWorkers.WithLabelValues(pool).Set(float64(workers))
+	return m.poolQueueDepth.WithLabelValues(pool), m.poolBusyWorkers.WithLabelValues(pool)
+}
+
+// incUploadsCut increments the uploads cut off by drain counter
+func (m *metrics) incUploadsCut() {
+	m.uploadsCutTotal.Inc()
+}
+
+// incRejected increments the rejected requests counter for a protocol
+func (m *metrics) incRejected(protocol string) {
+	m.rejectedTotal.WithLabelValues(protocol).Inc()
+}
+
+// observeConnState moves a connection between the per-state gauges
+func (m *metrics) observeConnState(prev http.ConnState, known bool, next http.ConnState) {
+	if known {
+		m.connections.WithLabelValues(prev.String()).Dec()
+	}
+	if next != http.StateClosed && next != http.StateHijacked {
+		m.connections.WithLabelValues(next.String()).Inc()
+	}
+}
+
+// incDrainBypass increments the drain bypass requests counter
+func (m *metrics) incDrainBypass() {
+	m.drainBypassTotal.Inc()
+}
+
+// incBudgetCancelled increments the budget-cancelled requests counter
+func (m *metrics) incBudgetCancelled() {
+	m.budgetCancelled.Inc()
+}
+
+// incShed increments the shed requests counter for a protocol
+func (m *metrics) incShed(protocol string) {
+	m.shedTotal.WithLabelValues(protocol).Inc()
+}
+
+// setDrainBudgetUsed sets the share of the drain budget used by recent drains
+func (m *metrics) setDrainBudgetUsed(ratio float64) {
+	m.drainBudgetUsed.Set(ratio)
+}
+
+// observeGRPCMethod records a completed gRPC request under its method and code
+func (m *metrics) observeGRPCMethod(method, code string, d time.Duration) {
+	m.grpcMethodTotal.WithLabelValues(method, code).Inc()
+	m.grpcDuration.WithLabelValues(method, code).Observe(d.Seconds())
+}
+
+// addStragglers counts straggling requests by outcome
+func (m *metrics) addStragglers(outcome string, n int64) {
+	m.stragglers.WithLabelValues(outcome).Add(float64(n))
+}
+
+// observeRoute records a completed HTTP request under its route template
+func (m *metrics) observeRoute(method, route string, d time.Duration) {
+	m.routeRequests.WithLabelValues(method, route).Inc()
+	m.routeDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// observeServerStop records how long a server took to stop
+func (m *metrics) observeServerStop(server, kind string, d time.Duration) {
+	m.serverStop.WithLabelValues(server, kind).Set(d.Seconds())
+	m.shutdownStep.WithLabelValues("server:" + server).Observe(d.Seconds())
+}
+
+// observeShutdownStep records how long a shutdown step took
+func (m *metrics) observeShutdownStep(step string, d time.Duration) {
+	m.shutdownStep.WithLabelValues(step).Observe(d.Seconds())
+}
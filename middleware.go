@@ -2,24 +2,94 @@ package gracewrap
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
 // httpMiddleware wraps an HTTP handler to track in-flight requests.
 func (g *Graceful) httpMiddleware(next http.Handler) http.Handler {
+	return g.serverMiddleware(nil, next)
+}
+
+// serverMiddleware is httpMiddleware for a handler served by server, which
+// selects the server's rejection policy (see SetRejectionPolicy).
+func (g *Graceful) serverMiddleware(server *http.Server, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		g.incInflight()
-		defer g.decInflight()
+		// Already tracked by an outer gracewrap middleware, e.g. a Chain'd
+		// handler wrapped in http.TimeoutHandler before reaching WrapHTTP
+		if _, ok := r.Context().Value(requestEntryKey{}).(*requestEntry); ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Liveness probes are answered even while draining
+		if g.config.LivenessPath != "" && r.URL.Path == g.config.LivenessPath {
+			g.LivenessHandler().ServeHTTP(w, r)
+			return
+		}
+
+		policy := g.routePolicy(r.URL.Path)
+		if policy != nil && policy.Untracked {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if g.rejecting() {
+			if g.config.DrainBypass == nil || !g.config.DrainBypass(r) {
+				g.rejectHTTP(w, r, server)
+				return
+			}
+			if g.metrics != nil {
+				g.metrics.incDrainBypass()
+			}
+		}
+
+		if g.overloaded() {
+			if g.metrics != nil {
+				g.metrics.incShed("http")
+			}
+			code := http.StatusTooManyRequests
+			if g.draining() {
+				code = http.StatusServiceUnavailable
+			}
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, http.StatusText(code), code)
+			return
+		}
+
+		ctx, done := g.trackRoute(r.Context(), policy, "http", r.Method, r.URL.Path)
+		hw := &hijackWriter{ResponseWriter: w, done: done}
+		defer hw.finish()
+		if c, ok := r.Context().Value(connKey{}).(net.Conn); ok {
+			g.conns.own(c, hw)
+			defer g.conns.disown(c, hw)
+		}
+		w = hw.writer()
+
+		w, r = g.guardUpload(w, r)
+
+		// Prompt clients and keep-alive pools to move to a healthy pod
+		if g.draining() {
+			w.Header().Set("Connection", "close")
+			if g.config.DrainingHeader != "" {
+				w.Header().Set(g.config.DrainingHeader, "true")
+			}
+		}
 
 		// Update metrics
 		if g.metrics != nil {
 			g.metrics.incHTTP()
 		}
 
+		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
+		g.observeRoute(r)
 	})
 }
 
@@ -30,15 +100,46 @@ func (g *Graceful) grpcUnaryInterceptor(
 	info *grpc.UnaryServerInfo,
 	handler grpc.UnaryHandler,
 ) (interface{}, error) {
-	g.incInflight()
-	defer g.decInflight()
+	return g.trackUnary(nil, ctx, req, info, handler)
+}
+
+// trackUnary tracks a unary RPC served by server (nil when unknown).
+func (g *Graceful) trackUnary(
+	server *grpc.Server,
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	policy := g.routePolicy(unaryMethod(info))
+	if policy != nil && policy.Untracked {
+		return handler(ctx, req)
+	}
+
+	if g.rejecting() {
+		return nil, g.rejectGRPC(server)
+	}
+
+	if g.overloaded() {
+		return nil, g.shedGRPC()
+	}
+
+	ctx, done := g.trackRoute(ctx, policy, "grpc", "unary", unaryMethod(info))
+	defer done()
 
 	// Update metrics
 	if g.metrics != nil {
 		g.metrics.incGRPC()
 	}
 
-	return handler(ctx, req)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	md, err := g.drainTrailer(ctx, err)
+	if md != nil {
+		_ = grpc.SetTrailer(ctx, md)
+	}
+	g.observeGRPCMethod(unaryMethod(info), err, start)
+	return resp, err
 }
 
 // grpcStreamInterceptor tracks in-flight streaming RPCs.
@@ -48,21 +149,104 @@ func (g *Graceful) grpcStreamInterceptor(
 	info *grpc.StreamServerInfo,
 	handler grpc.StreamHandler,
 ) error {
-	g.incInflight()
-	defer g.decInflight()
+	return g.trackStream(nil, srv, ss, info, handler)
+}
+
+// trackStream tracks a streaming RPC served by server (nil when unknown).
+func (g *Graceful) trackStream(
+	server *grpc.Server,
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	policy := g.routePolicy(streamMethod(info))
+	if policy != nil && policy.Untracked {
+		return handler(srv, ss)
+	}
+
+	if g.rejecting() {
+		return g.rejectGRPC(server)
+	}
+
+	if g.overloaded() {
+		return g.shedGRPC()
+	}
+
+	ctx, done := g.trackRoute(ss.Context(), policy, "grpc", "stream", streamMethod(info))
+	defer done()
 
 	// Update metrics
 	if g.metrics != nil {
 		g.metrics.incGRPC()
 	}
 
-	return handler(srv, &trackedStream{ServerStream: ss, graceful: g})
+	start := time.Now()
+	err := handler(srv, &trackedStream{ServerStream: ss, graceful: g, ctx: ctx})
+	md, err := g.drainTrailer(ctx, err)
+	if md != nil {
+		ss.SetTrailer(md)
+	}
+	g.observeGRPCMethod(streamMethod(info), err, start)
+	return err
+}
+
+// observeGRPCMethod records per-method metrics for a completed RPC when
+// Config.GRPCMethodMetrics is set.
+func (g *Graceful) observeGRPCMethod(method string, err error, start time.Time) {
+	if g.metrics == nil || !g.config.GRPCMethodMetrics {
+		return
+	}
+	g.metrics.observeGRPCMethod(method, status.Code(err).String(), time.Since(start))
+}
+
+// overloaded reports whether a new request would exceed Config.MaxInflight,
+// or Config.MaxInflightDuringDrain once drain has started.
+func (g *Graceful) overloaded() bool {
+	limit := g.config.MaxInflight
+	if g.draining() && g.config.MaxInflightDuringDrain > 0 {
+		limit = g.config.MaxInflightDuringDrain
+	}
+	return limit > 0 && g.inflightCount() >= int64(limit)
+}
+
+// shedGRPC returns the status error for an RPC shed by the in-flight limit.
+func (g *Graceful) shedGRPC() error {
+	if g.metrics != nil {
+		g.metrics.incShed("grpc")
+	}
+	return status.Error(codes.ResourceExhausted, "too many requests in flight")
+}
+
+// unaryMethod returns the full method name from info, tolerating nil.
+func unaryMethod(info *grpc.UnaryServerInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.FullMethod
+}
+
+// streamMethod returns the full method name from info, tolerating nil.
+func streamMethod(info *grpc.StreamServerInfo) string {
+	if info == nil {
+		return ""
+	}
+	return info.FullMethod
 }
 
 // trackedStream wraps a gRPC ServerStream to track the connection.
 type trackedStream struct {
 	grpc.ServerStream
 	graceful *Graceful
+	ctx      context.Context
+}
+
+// Context returns the stream context carrying the request tracking entry.
+func (ts *trackedStream) Context() context.Context {
+	if ts.ctx != nil {
+		return ts.ctx
+	}
+	return ts.ServerStream.Context()
 }
 
 // RecvMsg implements the grpc.ServerStream interface.
@@ -75,31 +259,39 @@ func (ts *trackedStream) SendMsg(m interface{}) error {
 	return ts.ServerStream.SendMsg(m)
 }
 
-// incInflight increments the in-flight request counter.
-func (g *Graceful) incInflight() {
+// incInflight increments the in-flight request counter and returns it.
+func (g *Graceful) incInflight() int64 {
 	g.inflight.mu.Lock()
 	g.inflight.n++
+	if g.inflight.n == 1 {
+		g.inflight.idle = make(chan struct{})
+	}
+	n := g.inflight.n
 	g.inflight.mu.Unlock()
 
 	// Update metrics
 	if g.metrics != nil {
-		g.metrics.updateInflight(g.inflight.n)
+		g.metrics.updateInflight(n)
 	}
+	return n
 }
 
-// decInflight decrements the in-flight request counter.
-func (g *Graceful) decInflight() {
+// decInflight decrements the in-flight request counter and returns it.
+func (g *Graceful) decInflight() int64 {
 	g.inflight.mu.Lock()
 	g.inflight.n--
+	g.inflight.completed++
 	if g.inflight.n == 0 {
-		g.inflight.cv.Broadcast()
+		close(g.inflight.idle)
 	}
+	n := g.inflight.n
 	g.inflight.mu.Unlock()
 
 	// Update metrics
 	if g.metrics != nil {
-		g.metrics.updateInflight(g.inflight.n)
+		g.metrics.updateInflight(n)
 	}
+	return n
 }
 
 // peerAddr extracts the peer address from a gRPC context.
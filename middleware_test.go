@@ -32,6 +32,7 @@ type fakeServerStream struct{ grpc.ServerStream }
 
 func (f *fakeServerStream) SendMsg(m interface{}) error { return nil }
 func (f *fakeServerStream) RecvMsg(m interface{}) error { return nil }
+func (f *fakeServerStream) Context() context.Context    { return context.Background() }
 
 func TestGRPCStreamInterceptor(t *testing.T) {
 	g := New(nil)
@@ -0,0 +1,32 @@
+package gracewrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSkipDelaysWithoutTraffic(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.LoadBalancerDelay = time.Second
+	cfg.HardStopTimeout = time.Second
+	cfg.SkipDelaysWithoutTraffic = true
+
+	g := New(&cfg)
+	start := time.Now()
+	g.Shutdown()
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("expected delays to be skipped without traffic, took %v", time.Since(start))
+	}
+
+	cfg.LoadBalancerDelay = 100 * time.Millisecond
+	cfg.HardStopTimeout = 0
+	g = New(&cfg)
+	g.httpMiddleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	start = time.Now()
+	g.Shutdown()
+	if time.Since(start) < 100*time.Millisecond {
+		t.Fatal("expected load balancer delay once traffic was observed")
+	}
+}
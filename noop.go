@@ -0,0 +1,234 @@
+package gracewrap
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc"
+	channelzsvc "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// noop is a Lifecycle that serves but skips delays, signal handling and
+// metrics, for local development and downstream unit tests.
+type noop struct {
+	mu          sync.Mutex
+	httpServers []*http.Server
+	grpcServers []*grpc.Server
+	health      []*health.Server
+	hooks       []func(ctx context.Context) error
+
+	once sync.Once
+	stop chan struct{}
+	err  error
+}
+
+// Noop returns a Lifecycle that starts servers like Graceful does, but
+// installs no signal handlers, records no metrics and shuts down immediately:
+// servers are closed and shutdown hooks run without any delay or drain wait.
+func Noop() Lifecycle {
+	return &noop{stop: make(chan struct{})}
+}
+
+// WrapHTTP binds server.Addr and serves server in a goroutine. Like
+// Graceful.WrapHTTP, it returns bind errors instead of dropping them.
+func (n *noop) WrapHTTP(server *http.Server) error {
+	if server.Addr == "" {
+		return errors.New("gracewrap: WrapHTTP needs server.Addr (use WrapHTTPWithListener or ListenAndWrapHTTP)")
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	n.addHTTP(server)
+	go func() { _ = server.Serve(listener) }()
+	return nil
+}
+
+// WrapHTTPTLS binds server.Addr and serves server over TLS in a goroutine,
+// validating its arguments like Graceful.WrapHTTPTLS.
+func (n *noop) WrapHTTPTLS(server *http.Server, certFile, keyFile string) error {
+	if server.Addr == "" {
+		return errors.New("gracewrap: WrapHTTPTLS needs server.Addr (use WrapHTTPTLSWithListener)")
+	}
+	if certFile == "" && keyFile == "" && !hasTLSCertificates(server.TLSConfig) {
+		return errors.New("gracewrap: WrapHTTPTLS needs cert/key files or a TLSConfig with certificates")
+	}
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+	n.addHTTP(server)
+	go func() { _ = server.ServeTLS(listener, certFile, keyFile) }()
+	return nil
+}
+
+// WrapHTTPWithListener serves server on listener in a goroutine.
+func (n *noop) WrapHTTPWithListener(server *http.Server, listener net.Listener) error {
+	n.addHTTP(server)
+	go func() { _ = server.Serve(listener) }()
+	return nil
+}
+
+// WrapGRPC serves server on listener in a goroutine.
+func (n *noop) WrapGRPC(server *grpc.Server, listener net.Listener) error {
+	n.addGRPC(server)
+	go func() { _ = server.Serve(listener) }()
+	return nil
+}
+
+// NewGRPCServer creates a gRPC server without request tracking. GRPCOption
+// values are translated rather than passed to grpc: TLS settings and the
+// optional services apply, interceptors run in the order given, and drain
+// policies and server names, which only matter during a drain, are ignored.
+func (n *noop) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	gwOpts, opts := splitGRPCOptions(opts)
+	cfg, err := gwOpts.tlsConfig()
+	if err != nil {
+		cfg = failingTLS(err)
+	}
+	return n.newGRPCServer(gwOpts, cfg, opts)
+}
+
+// newGRPCServer creates a gRPC server with the settings of gwOpts that make
+// sense outside a drain.
+func (n *noop) newGRPCServer(gwOpts grpcOptions, cfg *tls.Config, opts []grpc.ServerOption) *grpc.Server {
+	if cfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	}
+	opts = append(opts,
+		grpc.ChainUnaryInterceptor(gwOpts.unary...),
+		grpc.ChainStreamInterceptor(gwOpts.stream...),
+	)
+	server := grpc.NewServer(opts...)
+	if gwOpts.health {
+		hs := health.NewServer()
+		healthpb.RegisterHealthServer(server, hs)
+		n.mu.Lock()
+		n.health = append(n.health, hs)
+		n.mu.Unlock()
+	}
+	if gwOpts.reflection {
+		reflection.Register(server)
+	}
+	if gwOpts.channelz {
+		channelzsvc.RegisterChannelzServiceToServer(server)
+	}
+	n.addGRPC(server)
+	return server
+}
+
+// ServeGRPC creates a gRPC server like NewGRPCServer and serves it on addr
+// in a goroutine. TLS and bind errors are returned.
+func (n *noop) ServeGRPC(addr string, opts ...grpc.ServerOption) (*grpc.Server, net.Listener, error) {
+	gwOpts, opts := splitGRPCOptions(opts)
+	cfg, err := gwOpts.tlsConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	server := n.newGRPCServer(gwOpts, cfg, opts)
+	go func() { _ = server.Serve(listener) }()
+	return server, listener, nil
+}
+
+// Wait blocks until ctx is done or Fail is called, then shuts down and
+// returns the error passed to Fail.
+func (n *noop) Wait(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+	case <-n.stop:
+	}
+	n.Shutdown()
+	return n.err
+}
+
+// Shutdown closes every server and runs the shutdown hooks right away, with
+// no load balancer delay or drain wait.
+func (n *noop) Shutdown() {
+	n.Fail(nil)
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, hs := range n.health {
+		hs.Shutdown()
+	}
+	for _, s := range n.httpServers {
+		_ = s.Close()
+	}
+	for _, s := range n.grpcServers {
+		s.Stop()
+	}
+	for _, fn := range n.hooks {
+		_ = fn(context.Background())
+	}
+	n.httpServers, n.grpcServers, n.health, n.hooks = nil, nil, nil, nil
+}
+
+// Fail makes Wait return err. Only the first call counts.
+func (n *noop) Fail(err error) {
+	n.once.Do(func() {
+		n.err = err
+		close(n.stop)
+	})
+}
+
+// OnShutdown registers fn to run at Shutdown. name is unused; hooks run in
+// registration order.
+func (n *noop) OnShutdown(name string, fn func(ctx context.Context) error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.hooks = append(n.hooks, fn)
+}
+
+// Ready reports true until Shutdown or Fail.
+func (n *noop) Ready() bool {
+	select {
+	case <-n.stop:
+		return false
+	default:
+		return true
+	}
+}
+
+// HealthHandler answers 200 while ready and 503 afterwards.
+func (n *noop) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n.Ready() {
+			_, _ = w.Write([]byte("ready\n"))
+		} else {
+			http.Error(w, "draining", http.StatusServiceUnavailable)
+		}
+	})
+}
+
+// LivenessHandler always answers 200.
+func (n *noop) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("alive\n"))
+	})
+}
+
+// addHTTP registers server for shutdown.
+func (n *noop) addHTTP(server *http.Server) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.httpServers = append(n.httpServers, server)
+}
+
+// addGRPC registers server for shutdown.
+func (n *noop) addGRPC(server *grpc.Server) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.grpcServers = append(n.grpcServers, server)
+}
@@ -0,0 +1,113 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestNoopServesAndStopsImmediately(t *testing.T) {
+	lc := Noop()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})}
+	if err := lc.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+
+	hookRan := false
+	lc.OnShutdown("db", func(context.Context) error { hookRan = true; return nil })
+
+	cause := errors.New("dependency lost")
+	lc.Fail(cause)
+	start := time.Now()
+	if err := lc.Wait(context.Background()); err != cause {
+		t.Fatalf("expected Wait to return the failure cause, got %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("Noop should not apply shutdown delays")
+	}
+	if !hookRan || lc.Ready() {
+		t.Fatalf("expected hooks to run and readiness to drop (hook %v, ready %v)", hookRan, lc.Ready())
+	}
+	if _, err := http.Get("http://" + ln.Addr().String()); err == nil {
+		t.Fatal("expected server to be closed")
+	}
+}
+
+func TestNoopWrapHTTPReturnsBindErrors(t *testing.T) {
+	lc := Noop()
+	defer lc.Shutdown()
+
+	if err := lc.WrapHTTP(&http.Server{}); err == nil {
+		t.Fatal("expected an error without server.Addr")
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+	if err := lc.WrapHTTP(&http.Server{Addr: ln.Addr().String()}); err == nil {
+		t.Fatal("expected a bind error for an address in use")
+	}
+	if err := lc.WrapHTTPTLS(&http.Server{Addr: "127.0.0.1:0"}, "", ""); err == nil {
+		t.Fatal("expected an error without certificates")
+	}
+}
+
+func TestNoopGRPCOptionsTranslated(t *testing.T) {
+	lc := Noop()
+	defer lc.Shutdown()
+
+	var intercepted bool
+	srv, ln, err := lc.ServeGRPC("127.0.0.1:0",
+		WithHealthService(),
+		WithInterceptorPlacement(Innermost),
+		WithUnaryInterceptors(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			intercepted = true
+			return handler(ctx, req)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("serve grpc: %v", err)
+	}
+	if _, ok := srv.GetServiceInfo()["grpc.health.v1.Health"]; !ok {
+		t.Fatal("expected WithHealthService to register the health service")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	conn, err := grpc.DialContext(ctx, ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected SERVING, got %v (%v)", resp, err)
+	}
+	if !intercepted {
+		t.Fatal("expected WithUnaryInterceptors to install the interceptor")
+	}
+
+	if _, _, err := lc.ServeGRPC("127.0.0.1:0", WithTLS("missing.crt", "missing.key")); err == nil {
+		t.Fatal("expected TLS errors to be returned")
+	}
+}
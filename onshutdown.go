@@ -0,0 +1,38 @@
+package gracewrap
+
+import "context"
+
+// RegisterOnShutdown registers f, written for http.Server.RegisterOnShutdown,
+// as the shutdown hook name. Unlike a func registered on the server itself,
+// which runs whenever that server's Shutdown happens to be called, f takes
+// part in After ordering, gets a hook budget and appears in the report.
+// f cannot be cancelled; once its budget runs out shutdown moves on and f
+// keeps running in the background.
+func (g *Graceful) RegisterOnShutdown(name string, f func()) {
+	g.OnShutdown(name, func(ctx context.Context) error {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			f()
+		}()
+		select {
+		case <-done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// ShutdownHook returns a func to pass to http.Server.RegisterOnShutdown so
+// that a server shut down outside gracewrap (e.g. by a framework's own
+// Shutdown) starts gracewrap's shutdown, running its phases and hooks for
+// the rest of the process. It does nothing once drain has started.
+func (g *Graceful) ShutdownHook() func() {
+	return func() {
+		if g.draining() {
+			return
+		}
+		g.shutdown()
+	}
+}
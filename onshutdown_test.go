@@ -0,0 +1,73 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegisterOnShutdownIsOrdered(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 300 * time.Millisecond
+	g.config.HookTimeoutFloor = 50 * time.Millisecond
+
+	var mu sync.Mutex
+	var order []string
+	record := func(s string) {
+		mu.Lock()
+		order = append(order, s)
+		mu.Unlock()
+	}
+	g.RegisterOnShutdown("notify", func() { record("notify") })
+	g.OnShutdown("db", func(ctx context.Context) error {
+		record("db")
+		return nil
+	})
+	if err := g.After("notify", "db"); err != nil {
+		t.Fatal(err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	g.RegisterOnShutdown("stuck", func() { <-release })
+
+	g.Shutdown()
+
+	if len(order) != 2 || order[0] != "notify" || order[1] != "db" {
+		t.Fatalf("expected notify before db, got %v", order)
+	}
+	if _, ok := g.Report().HookBudgets["notify"]; !ok {
+		t.Fatalf("expected notify in report, got %v", g.Report().HookBudgets)
+	}
+}
+
+func TestShutdownHookStartsShutdown(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	srv := &http.Server{Addr: "127.0.0.1:0"}
+	if err := g.WrapHTTP(srv); err != nil {
+		t.Fatal(err)
+	}
+	srv.RegisterOnShutdown(g.ShutdownHook())
+
+	ran := make(chan struct{})
+	g.OnShutdown("cleanup", func(ctx context.Context) error {
+		close(ran)
+		return nil
+	})
+
+	_ = srv.Shutdown(context.Background())
+	select {
+	case <-ran:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown hooks did not run after the server was shut down directly")
+	}
+	if g.Ready() {
+		t.Fatal("expected not ready after the server was shut down")
+	}
+}
@@ -0,0 +1,69 @@
+package gracewrap
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutHandlerCannotLeakInflight(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 5 * time.Second
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	release := make(chan struct{})
+	defer close(release)
+	stuck := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // abandoned by TimeoutHandler, never returns during the test
+	})
+	srv := &http.Server{
+		Handler: http.TimeoutHandler(g.Chain(stuck, Innermost), 50*time.Millisecond, "timeout"),
+	}
+	if err := g.WrapHTTPWithListener(srv, ln); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected timeout response, got %d", resp.StatusCode)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for g.inflightCount() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("abandoned request still counted: %d in flight", g.inflightCount())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	start := time.Now()
+	g.Shutdown()
+	if time.Since(start) > time.Second {
+		t.Fatal("drain was wedged by the abandoned request")
+	}
+}
+
+func TestPanickingHandlerReleasesInflight(t *testing.T) {
+	g := New(nil)
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+	func() {
+		defer func() { _ = recover() }()
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	if n := g.inflightCount(); n != 0 {
+		t.Fatalf("expected panic to release tracking, got %d in flight", n)
+	}
+}
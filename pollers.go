@@ -0,0 +1,80 @@
+package gracewrap
+
+import (
+	"context"
+	"fmt"
+)
+
+// poller is a background poller stopped as soon as drain starts.
+type poller struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// StopOnDrain registers a feature-flag or remote-config SDK client (or any
+// background poller) to be stopped as soon as drain starts, so its egress
+// doesn't outlive readiness. p may implement any of the common shapes:
+//
+//	Stop()                           // ConfigCat, generic tickers
+//	Stop() error
+//	Close()
+//	Close() error                    // LaunchDarkly, Unleash, io.Closer
+//	Destroy()                        // Split
+//	Shutdown(context.Context) error
+//
+// StopOnDrain returns an error if p implements none of them.
+func (g *Graceful) StopOnDrain(name string, p interface{}) error {
+	stop, err := pollerStopFunc(p)
+	if err != nil {
+		return fmt.Errorf("gracewrap: StopOnDrain(%q): %w", name, err)
+	}
+	g.pollers.mu.Lock()
+	defer g.pollers.mu.Unlock()
+	g.pollers.list = append(g.pollers.list, poller{name: name, stop: stop})
+	return nil
+}
+
+// pollerStopFunc adapts p to a stop function.
+func pollerStopFunc(p interface{}) (func(ctx context.Context) error, error) {
+	switch v := p.(type) {
+	case interface{ Shutdown(context.Context) error }:
+		return v.Shutdown, nil
+	case interface{ Stop() error }:
+		return func(context.Context) error { return v.Stop() }, nil
+	case interface{ Stop() }:
+		return func(context.Context) error { v.Stop(); return nil }, nil
+	case interface{ Close() error }:
+		return func(context.Context) error { return v.Close() }, nil
+	case interface{ Close() }:
+		return func(context.Context) error { v.Close(); return nil }, nil
+	case interface{ Destroy() }:
+		return func(context.Context) error { v.Destroy(); return nil }, nil
+	case func():
+		return func(context.Context) error { v(); return nil }, nil
+	}
+	return nil, fmt.Errorf("unsupported type %T: needs Stop, Close, Destroy or Shutdown", p)
+}
+
+// pollerCount returns the number of pollers registered with StopOnDrain.
+func (g *Graceful) pollerCount() int {
+	g.pollers.mu.Lock()
+	defer g.pollers.mu.Unlock()
+	return len(g.pollers.list)
+}
+
+// stopPollers stops every registered poller in the background, bounded by ctx.
+func (g *Graceful) stopPollers(ctx context.Context) {
+	g.pollers.mu.Lock()
+	pollers := append([]poller(nil), g.pollers.list...)
+	g.pollers.mu.Unlock()
+
+	for _, p := range pollers {
+		go func(p poller) {
+			if err := p.stop(ctx); err != nil {
+				g.logger.Printf("Stopping poller %q error: %v", p.name, err)
+			} else {
+				g.logger.Printf("Stopped poller %q", p.name)
+			}
+		}(p)
+	}
+}
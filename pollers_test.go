@@ -0,0 +1,41 @@
+package gracewrap
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type closerPoller struct{ closed atomic.Bool }
+
+func (c *closerPoller) Close() error { c.closed.Store(true); return nil }
+
+type destroyPoller struct{ destroyed atomic.Bool }
+
+func (d *destroyPoller) Destroy() { d.destroyed.Store(true) }
+
+func TestStopOnDrainStopsPollersEarly(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 200 * time.Millisecond
+	g.config.HardStopTimeout = 0
+
+	ld := &closerPoller{}
+	split := &destroyPoller{}
+	if err := g.StopOnDrain("launchdarkly", ld); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.StopOnDrain("split", split); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.StopOnDrain("bogus", 42); err == nil {
+		t.Fatal("expected error for unsupported poller")
+	}
+
+	go g.Shutdown()
+
+	// Pollers stop during the load balancer delay, not after it
+	time.Sleep(50 * time.Millisecond)
+	if !ld.closed.Load() || !split.destroyed.Load() {
+		t.Fatal("expected pollers to be stopped early in the drain")
+	}
+}
@@ -0,0 +1,81 @@
+package gracewrap
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+)
+
+// captureProfiles writes a heap snapshot and starts a CPU profile in
+// Config.ProfileDir as drain begins. The CPU profile runs for
+// Config.ProfileDuration, or until the returned func is called, which
+// returns the paths written.
+func (g *Graceful) captureProfiles() func() []string {
+	if g.config.ProfileDir == "" {
+		return func() []string { return nil }
+	}
+	duration := g.config.ProfileDuration
+	if duration <= 0 {
+		duration = 5 * time.Second
+	}
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+	var paths []string
+
+	heapPath := filepath.Join(g.config.ProfileDir, fmt.Sprintf("heap-%s.pprof", stamp))
+	if err := writeProfile(heapPath, func(f *os.File) error { return pprof.Lookup("heap").WriteTo(f, 0) }); err != nil {
+		g.logger.Printf("Heap profile at drain start failed: %v", err)
+	} else {
+		paths = append(paths, heapPath)
+	}
+
+	cpuPath := filepath.Join(g.config.ProfileDir, fmt.Sprintf("cpu-%s.pprof", stamp))
+	f, err := os.Create(cpuPath)
+	if err == nil {
+		err = pprof.StartCPUProfile(f)
+		if err != nil {
+			f.Close()
+			os.Remove(cpuPath)
+		}
+	}
+	if err != nil {
+		// e.g. a CPU profile is already running via /debug/pprof
+		g.logger.Printf("CPU profile at drain start failed: %v", err)
+		return func() []string { return paths }
+	}
+
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		timer := time.NewTimer(duration)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-stop:
+		}
+		pprof.StopCPUProfile()
+		f.Close()
+	}()
+
+	g.logger.Printf("Capturing drain profiles in %s", g.config.ProfileDir)
+	return func() []string {
+		close(stop)
+		<-stopped
+		return append(paths, cpuPath)
+	}
+}
+
+// writeProfile creates path and writes a profile to it with write.
+func writeProfile(path string, write func(f *os.File) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
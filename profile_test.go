@@ -0,0 +1,33 @@
+package gracewrap
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDrainProfilesInReport(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.ProfileDir = t.TempDir()
+
+	g.Shutdown()
+
+	profiles := g.Report().Profiles
+	if len(profiles) != 2 {
+		t.Fatalf("expected heap and CPU profiles, got %v", profiles)
+	}
+	for _, p := range profiles {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("profile %s: %v", p, err)
+		}
+		if info.Size() == 0 {
+			t.Fatalf("profile %s is empty", p)
+		}
+		if !strings.HasPrefix(p, g.config.ProfileDir) {
+			t.Fatalf("profile %s outside ProfileDir", p)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package gracewrap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// readinessCheck is a named readiness condition (see AddReadinessCheck).
+type readinessCheck struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// AddReadinessCheck registers fn as a condition for readiness: HealthHandler
+// returns 503 while draining or while any check fails, so a pod that cannot
+// reach its database stops receiving traffic without shutting down.
+//
+// Checks run concurrently, bounded by Config.ReadinessCheckTimeout. Probes
+// arriving while a run is in progress share its results instead of starting
+// another; with Config.ReadinessCheckInterval the checks run in the
+// background instead and probes get the latest results.
+func (g *Graceful) AddReadinessCheck(name string, fn func(ctx context.Context) error) {
+	g.checks.mu.Lock()
+	g.checks.list = append(g.checks.list, readinessCheck{name: name, fn: fn})
+	g.checks.mu.Unlock()
+	if g.config.ReadinessCheckInterval > 0 {
+		g.checks.loop.Do(func() { go g.readinessLoop() })
+	}
+}
+
+// readinessLoop runs the checks every Config.ReadinessCheckInterval until
+// shutdown completes.
+func (g *Graceful) readinessLoop() {
+	ticker := time.NewTicker(g.config.ReadinessCheckInterval)
+	defer ticker.Stop()
+	for {
+		_, _ = g.checkReadiness(context.Background())
+		select {
+		case <-ticker.C:
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// readinessFailures returns the failed readiness checks: the latest
+// background results with Config.ReadinessCheckInterval, otherwise those of
+// a run joined or started now. err is ctx's error if it ends first.
+func (g *Graceful) readinessFailures(ctx context.Context) (failed []error, err error) {
+	if g.config.ReadinessCheckInterval > 0 {
+		g.checks.mu.Lock()
+		failed, ran := g.checks.last, g.checks.ran
+		g.checks.mu.Unlock()
+		if ran {
+			return failed, nil
+		}
+	}
+	return g.checkReadiness(ctx)
+}
+
+// checkReadiness joins the run in progress or starts one, and waits for its
+// results or for ctx to end.
+func (g *Graceful) checkReadiness(ctx context.Context) ([]error, error) {
+	g.checks.mu.Lock()
+	if len(g.checks.list) == 0 {
+		g.checks.mu.Unlock()
+		return nil, nil
+	}
+	running := g.checks.running
+	if running == nil {
+		running = make(chan struct{})
+		g.checks.running = running
+		go g.runShared(running)
+	}
+	g.checks.mu.Unlock()
+
+	select {
+	case <-running:
+		g.checks.mu.Lock()
+		defer g.checks.mu.Unlock()
+		return g.checks.last, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// runShared runs the checks once for every caller waiting on running.
+func (g *Graceful) runShared(running chan struct{}) {
+	timeout := g.config.ReadinessCheckTimeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	failed := g.runReadinessChecks(ctx)
+
+	g.checks.mu.Lock()
+	g.checks.last, g.checks.ran, g.checks.running = failed, true, nil
+	g.checks.mu.Unlock()
+	close(running)
+}
+
+// runReadinessChecks runs every registered check and returns the failures,
+// in registration order.
+func (g *Graceful) runReadinessChecks(ctx context.Context) []error {
+	g.checks.mu.Lock()
+	checks := append([]readinessCheck(nil), g.checks.list...)
+	g.checks.mu.Unlock()
+
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c readinessCheck) {
+			defer wg.Done()
+			if err := c.fn(ctx); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", c.name, err)
+			}
+		}(i, c)
+	}
+	wg.Wait()
+
+	var failed []error
+	for _, err := range errs {
+		if err != nil {
+			failed = append(failed, err)
+		}
+	}
+	return failed
+}
+
+// notReadyMessage formats failed readiness checks for a probe response.
+func notReadyMessage(failed []error) string {
+	lines := make([]string, len(failed))
+	for i, err := range failed {
+		lines[i] = err.Error()
+	}
+	return "not ready: " + strings.Join(lines, "; ")
+}
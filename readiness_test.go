@@ -0,0 +1,122 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReadinessChecks(t *testing.T) {
+	g := newTestGraceful(t)
+	var dbDown atomic.Bool
+	g.AddReadinessCheck("cache", func(ctx context.Context) error { return nil })
+	g.AddReadinessCheck("db", func(ctx context.Context) error {
+		if dbDown.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	probe := func() *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		g.HealthHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		return rr
+	}
+
+	if rr := probe(); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 with passing checks, got %d", rr.Code)
+	}
+
+	dbDown.Store(true)
+	rr := probe()
+	if rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "db: connection refused") {
+		t.Fatalf("expected 503 naming the failed check, got %d %q", rr.Code, rr.Body.String())
+	}
+	if !g.Ready() {
+		t.Fatal("a failing check must not start draining")
+	}
+
+	dbDown.Store(false)
+	g.setReady(false)
+	if rr := probe(); rr.Code != http.StatusServiceUnavailable || !strings.Contains(rr.Body.String(), "draining") {
+		t.Fatalf("expected 503 while draining, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestReadinessChecksSingleFlight(t *testing.T) {
+	g := newTestGraceful(t)
+	var calls atomic.Int32
+	release := make(chan struct{})
+	g.AddReadinessCheck("db", func(ctx context.Context) error {
+		calls.Add(1)
+		<-release
+		return nil
+	})
+
+	const probes = 5
+	codes := make(chan int, probes)
+	for i := 0; i < probes; i++ {
+		go func() {
+			rr := httptest.NewRecorder()
+			g.HealthHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			codes <- rr.Code
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < probes; i++ {
+		if code := <-codes; code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", code)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("expected concurrent probes to share one run, got %d", n)
+	}
+}
+
+func TestReadinessChecksCached(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.ReadinessCheckInterval = 20 * time.Millisecond
+	var calls atomic.Int32
+	var down atomic.Bool
+	g.AddReadinessCheck("db", func(ctx context.Context) error {
+		calls.Add(1)
+		if down.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	probe := func() int {
+		rr := httptest.NewRecorder()
+		g.HealthHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+		return rr.Code
+	}
+	waitFor := func(code int) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for probe() != code {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected probes to answer %d", code)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	waitFor(http.StatusOK)
+	before := calls.Load()
+	for i := 0; i < 50; i++ {
+		probe()
+	}
+	if n := calls.Load() - before; n > 3 {
+		t.Fatalf("expected probes to be answered from cache, got %d extra runs", n)
+	}
+
+	down.Store(true)
+	waitFor(http.StatusServiceUnavailable)
+}
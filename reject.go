@@ -0,0 +1,159 @@
+package gracewrap
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RejectionPolicy describes the response given to requests rejected while
+// draining, so it can match the retry semantics of the fronting proxy
+// (some treat 503 as "remove", others retry 429).
+type RejectionPolicy struct {
+	// HTTP status code (defaults to 503)
+	StatusCode int
+	// Extra HTTP response headers
+	Header http.Header
+	// HTTP response body (defaults to "draining")
+	Body string
+	// JSON-encoded HTTP response body; takes precedence over Body
+	JSONBody interface{}
+	// Retry-After value sent with HTTP rejections (rounded up to whole seconds)
+	RetryAfter time.Duration
+	// gRPC status code (defaults to codes.Unavailable)
+	GRPCCode codes.Code
+	// gRPC status message (defaults to "draining")
+	GRPCMessage string
+}
+
+// SetRejectionPolicy overrides Config.Rejection for requests served by server.
+// Its GRPCCode and GRPCMessage are unused; see SetGRPCRejectionPolicy.
+func (g *Graceful) SetRejectionPolicy(server *http.Server, p RejectionPolicy) {
+	g.rejection.mu.Lock()
+	defer g.rejection.mu.Unlock()
+	if g.rejection.byServer == nil {
+		g.rejection.byServer = make(map[*http.Server]RejectionPolicy)
+	}
+	g.rejection.byServer[server] = p
+}
+
+// SetGRPCRejectionPolicy overrides Config.Rejection for RPCs served by
+// server, which must have been created with NewGRPCServer or ServeGRPC. Only
+// GRPCCode and GRPCMessage apply.
+func (g *Graceful) SetGRPCRejectionPolicy(server *grpc.Server, p RejectionPolicy) {
+	g.rejection.mu.Lock()
+	defer g.rejection.mu.Unlock()
+	if g.rejection.byGRPC == nil {
+		g.rejection.byGRPC = make(map[*grpc.Server]RejectionPolicy)
+	}
+	g.rejection.byGRPC[server] = p
+}
+
+// WithRejectionPolicy sets the rejection policy of the server created by
+// NewGRPCServer or ServeGRPC (see SetGRPCRejectionPolicy).
+func WithRejectionPolicy(p RejectionPolicy) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.rejection = &p }}
+}
+
+// rejectionPolicy returns the policy for server (nil means the default).
+func (g *Graceful) rejectionPolicy(server *http.Server) RejectionPolicy {
+	if server != nil {
+		g.rejection.mu.Lock()
+		p, ok := g.rejection.byServer[server]
+		g.rejection.mu.Unlock()
+		if ok {
+			return p
+		}
+	}
+	return g.config.Rejection
+}
+
+// rejecting reports whether new requests should be rejected because drain has started.
+func (g *Graceful) rejecting() bool {
+	return g.config.RejectWhileDraining && g.draining()
+}
+
+// rejectHTTP answers a request that arrived while draining. Idempotent
+// requests are first offered to the drain retry forwarder, if configured.
+func (g *Graceful) rejectHTTP(w http.ResponseWriter, r *http.Request, server *http.Server) {
+	if g.forwardRejected(w, r) {
+		return
+	}
+
+	g.countRejected()
+	if g.metrics != nil {
+		g.metrics.incRejected("http")
+	}
+
+	p := g.rejectionPolicy(server)
+	code := p.StatusCode
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+	for k, v := range p.Header {
+		w.Header()[k] = v
+	}
+
+	body := []byte(p.Body)
+	contentType := "text/plain; charset=utf-8"
+	if p.JSONBody != nil {
+		b, err := json.Marshal(p.JSONBody)
+		if err != nil {
+			g.logger.Printf("Rejection JSON body error: %v", err)
+		} else {
+			body = b
+			contentType = "application/json"
+		}
+	}
+	if len(body) == 0 {
+		body = []byte("draining\n")
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if p.RetryAfter > 0 {
+		secs := int64((p.RetryAfter + time.Second - 1) / time.Second)
+		w.Header().Set("Retry-After", strconv.FormatInt(secs, 10))
+	}
+	w.Header().Set("Connection", "close")
+	w.WriteHeader(code)
+	_, _ = w.Write(body)
+}
+
+// grpcRejectionPolicy returns the policy for server (nil means the default).
+func (g *Graceful) grpcRejectionPolicy(server *grpc.Server) RejectionPolicy {
+	if server != nil {
+		g.rejection.mu.Lock()
+		p, ok := g.rejection.byGRPC[server]
+		g.rejection.mu.Unlock()
+		if ok {
+			return p
+		}
+	}
+	return g.config.Rejection
+}
+
+// rejectGRPC returns the status error for an RPC to server that arrived
+// while draining.
+func (g *Graceful) rejectGRPC(server *grpc.Server) error {
+	g.countRejected()
+	if g.metrics != nil {
+		g.metrics.incRejected("grpc")
+	}
+
+	p := g.grpcRejectionPolicy(server)
+	code := p.GRPCCode
+	if code == codes.OK {
+		code = codes.Unavailable
+	}
+	msg := p.GRPCMessage
+	if msg == "" {
+		msg = "draining"
+	}
+	return status.Error(code, msg)
+}
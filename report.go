@@ -0,0 +1,162 @@
+package gracewrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ShutdownReport summarizes the most recent shutdown. It marshals to JSON
+// (see Config.ReportWriter) for soak tests and log pipelines; durations are
+// in nanoseconds.
+type ShutdownReport struct {
+	// Duration is how long the whole shutdown took.
+	Duration time.Duration `json:"duration"`
+	// Phases is how long each shutdown phase took, by name: "jitter",
+	// "load_balancer_delay", "stop_servers", "inflight_wait", "hard_stop".
+	Phases map[string]time.Duration `json:"phases,omitempty"`
+	// Servers is how each server stopped, in the order they finished.
+	Servers []ServerResult `json:"servers,omitempty"`
+	// Hooks is how each shutdown hook ended, in the order they finished.
+	Hooks []HookResult `json:"hooks,omitempty"`
+	// InflightAtStop is the number of requests in flight when servers began
+	// stopping; InflightCompleted of them finished and InflightAbandoned were
+	// still running when the drain gave up on them.
+	InflightAtStop    int64 `json:"inflight_at_stop"`
+	InflightCompleted int64 `json:"inflight_completed"`
+	InflightAbandoned int64 `json:"inflight_abandoned"`
+	// HookBudgets is the timeout allocated to each shutdown hook, by name.
+	HookBudgets map[string]time.Duration `json:"hook_budgets,omitempty"`
+	// DrainSlices is how the drain progressed, one entry per time slice.
+	DrainSlices []DrainSlice `json:"drain_slices,omitempty"`
+	// DrainDuration is how long servers took to stop and in-flight requests
+	// to finish, against a budget of Config.DrainTimeout.
+	DrainDuration time.Duration `json:"drain_duration,omitempty"`
+	// Profiles are the heap and CPU profiles captured at drain start
+	// (Config.ProfileDir) and the goroutine profile written when the drain
+	// deadline passed with requests in flight (Config.StallProfilePath).
+	Profiles []string `json:"profiles,omitempty"`
+	// ClockJumps is the number of wall-clock jumps seen (Config.ClockAudit).
+	ClockJumps int `json:"clock_jumps,omitempty"`
+	// DeadlineExceeded reports that requests were still in flight at the
+	// drain deadline, or that a ShutdownContext deadline cut the shutdown
+	// short.
+	DeadlineExceeded bool `json:"deadline_exceeded,omitempty"`
+	// Forced reports that the shutdown was forced (see ForceShutdown).
+	Forced bool `json:"forced,omitempty"`
+}
+
+// ServerResult is how a server stopped.
+type ServerResult struct {
+	Name     string        `json:"name"`
+	Kind     string        `json:"kind"`
+	Duration time.Duration `json:"duration"`
+	// Error is why the server did not stop cleanly (e.g. its drain deadline
+	// passed and it was stopped outright).
+	Error string `json:"error,omitempty"`
+}
+
+// HookResult is how a shutdown hook ended.
+type HookResult struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	// Error is what the hook returned, if it failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Report returns a copy of the shutdown report, or nil if no shutdown has run.
+func (g *Graceful) Report() *ShutdownReport {
+	g.report.mu.Lock()
+	defer g.report.mu.Unlock()
+	if g.report.r == nil {
+		return nil
+	}
+	r := *g.report.r
+	if r.HookBudgets != nil {
+		r.HookBudgets = make(map[string]time.Duration, len(g.report.r.HookBudgets))
+		for k, v := range g.report.r.HookBudgets {
+			r.HookBudgets[k] = v
+		}
+	}
+	if r.Phases != nil {
+		r.Phases = make(map[string]time.Duration, len(g.report.r.Phases))
+		for k, v := range g.report.r.Phases {
+			r.Phases[k] = v
+		}
+	}
+	r.Servers = append([]ServerResult(nil), g.report.r.Servers...)
+	r.Hooks = append([]HookResult(nil), g.report.r.Hooks...)
+	r.DrainSlices = append([]DrainSlice(nil), g.report.r.DrainSlices...)
+	r.Profiles = append([]string(nil), g.report.r.Profiles...)
+	return &r
+}
+
+// updateReport applies fn to the report under lock, creating it if needed.
+func (g *Graceful) updateReport(fn func(r *ShutdownReport)) {
+	g.report.mu.Lock()
+	defer g.report.mu.Unlock()
+	if g.report.r == nil {
+		g.report.r = &ShutdownReport{}
+	}
+	fn(g.report.r)
+}
+
+// shutdownErr describes how the completed shutdown fell short of a clean
+// drain, joining ErrForcedStop, ErrDrainDeadlineExceeded and one
+// ErrHookFailed per failed hook; it is nil after a clean shutdown.
+func (g *Graceful) shutdownErr() error {
+	r := g.Report()
+	if r == nil {
+		return nil
+	}
+	var errs []error
+	if r.Forced {
+		errs = append(errs, ErrForcedStop)
+	}
+	if r.DeadlineExceeded {
+		errs = append(errs, ErrDrainDeadlineExceeded)
+	}
+	for _, h := range r.Hooks {
+		if h.Error != "" {
+			errs = append(errs, fmt.Errorf("%w: %q: %s", ErrHookFailed, h.Name, h.Error))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordPhase adds how long a shutdown phase took to the report and the
+// step duration metric.
+func (g *Graceful) recordPhase(name string, start time.Time) {
+	d := time.Since(start)
+	if g.metrics != nil {
+		g.metrics.observeShutdownStep(name, d)
+	}
+	g.updateReport(func(r *ShutdownReport) {
+		if r.Phases == nil {
+			r.Phases = make(map[string]time.Duration)
+		}
+		r.Phases[name] += d
+	})
+}
+
+// writeReport writes the shutdown report as a JSON line to
+// Config.ReportWriter and, with Config.LogReport, to the logger.
+func (g *Graceful) writeReport() {
+	if g.config.ReportWriter == nil && !g.config.LogReport {
+		return
+	}
+	line, err := json.Marshal(g.Report())
+	if err != nil {
+		g.logger.Printf("Encoding shutdown report failed: %v", err)
+		return
+	}
+	if g.config.LogReport {
+		g.logger.Printf("Shutdown report: %s", line)
+	}
+	if g.config.ReportWriter != nil {
+		if _, err := g.config.ReportWriter.Write(append(line, '\n')); err != nil {
+			g.logger.Printf("Writing shutdown report failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package gracewrap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestShutdownReportJSON(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 10 * time.Millisecond
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = time.Second
+	var out bytes.Buffer
+	g.config.ReportWriter = &out
+
+	srv, _, err := g.ListenAndWrapHTTP("127.0.0.1:0", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetServerName(srv, "public")
+	g.OnShutdown("flush", func(ctx context.Context) error { return nil })
+	g.OnShutdown("close-db", func(ctx context.Context) error { return errors.New("busy") })
+
+	g.Shutdown()
+
+	var r ShutdownReport
+	if err := json.Unmarshal(out.Bytes(), &r); err != nil {
+		t.Fatalf("report is not a JSON line: %v (%q)", err, out.String())
+	}
+	if r.Duration <= 0 || r.Phases["load_balancer_delay"] < 10*time.Millisecond {
+		t.Fatalf("expected durations, got %v and phases %v", r.Duration, r.Phases)
+	}
+	for _, phase := range []string{"stop_servers", "inflight_wait"} {
+		if _, ok := r.Phases[phase]; !ok {
+			t.Fatalf("expected phase %q in %v", phase, r.Phases)
+		}
+	}
+	if len(r.Servers) != 1 || r.Servers[0].Name != "public" || r.Servers[0].Kind != NodeHTTP || r.Servers[0].Error != "" {
+		t.Fatalf("unexpected servers %+v", r.Servers)
+	}
+	hooks := map[string]string{}
+	for _, h := range r.Hooks {
+		hooks[h.Name] = h.Error
+	}
+	if len(hooks) != 2 || hooks["flush"] != "" || hooks["close-db"] != "busy" {
+		t.Fatalf("unexpected hooks %+v", r.Hooks)
+	}
+}
+
+func TestShutdownReportInflight(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = 50 * time.Millisecond
+
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/quick")
+	_, _ = g.trackRequest(context.Background(), "http", "GET", "/stuck")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		done()
+	}()
+	// Holds the drain until its deadline has passed
+	g.OnShutdown("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	g.Shutdown()
+
+	r := g.Report()
+	if r.InflightAtStop != 2 || r.InflightCompleted != 1 || r.InflightAbandoned != 1 {
+		t.Fatalf("expected 2 in flight, 1 completed, 1 abandoned; got %d, %d, %d",
+			r.InflightAtStop, r.InflightCompleted, r.InflightAbandoned)
+	}
+}
+
+func TestShutdownStepMetrics(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 10 * time.Millisecond
+	g.config.HardStopTimeout = 0
+	g.config.DrainTimeout = time.Second
+
+	srv, _, err := g.ListenAndWrapHTTP("127.0.0.1:0", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetServerName(srv, "public")
+	g.OnShutdown("flush", func(ctx context.Context) error { return nil })
+
+	g.Shutdown()
+
+	for _, step := range []string{"load_balancer_delay", "stop_servers", "inflight_wait", "server:public", "hook:flush"} {
+		assertMetric(t, g, `gracewrap_shutdown_step_duration_seconds_count{step="`+step+`"} 1`)
+	}
+}
@@ -0,0 +1,41 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestRequestCallbacks(t *testing.T) {
+	g := New(nil)
+	var starts, ends []RequestInfo
+	g.config.OnRequestStart = func(ri RequestInfo) { starts = append(starts, ri) }
+	g.config.OnRequestEnd = func(ri RequestInfo) { ends = append(ends, ri) }
+
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Annotate(r.Context(), "tenant", "acme")
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	_, err := g.grpcUnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/svc/Method"},
+		func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	if err != nil {
+		t.Fatalf("unexpected err: %v", err)
+	}
+
+	if len(starts) != 2 || len(ends) != 2 {
+		t.Fatalf("expected 2 starts and ends, got %d/%d", len(starts), len(ends))
+	}
+	if s := starts[0]; s.Kind != "http" || s.Method != http.MethodPost || s.Path != "/orders" || s.Inflight != 1 || s.Duration != 0 {
+		t.Fatalf("unexpected http start: %+v", s)
+	}
+	if e := ends[0]; e.ID != starts[0].ID || e.Inflight != 0 || e.Labels["tenant"] != "acme" {
+		t.Fatalf("unexpected http end: %+v", e)
+	}
+	if s := starts[1]; s.Kind != "grpc" || s.Path != "/svc/Method" || s.ID <= starts[0].ID {
+		t.Fatalf("unexpected grpc start: %+v", s)
+	}
+}
@@ -0,0 +1,171 @@
+package gracewrap
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpguts"
+)
+
+// DrainRetryConfig configures forwarding of idempotent requests rejected
+// during drain to another replica before giving up with a 503.
+type DrainRetryConfig struct {
+	// Forwarder sends the request elsewhere, e.g. a RoundTripper that rewrites
+	// the URL to a peer replica. Required.
+	Forwarder http.RoundTripper
+	// Idempotent selects requests that are safe to retry. Defaults to GET,
+	// HEAD and OPTIONS requests plus requests carrying an Idempotency-Key header.
+	Idempotent func(r *http.Request) bool
+	// QueueSize bounds the number of requests being forwarded at once;
+	// requests beyond it are rejected immediately. Defaults to 64.
+	QueueSize int
+	// Attempts is the number of forwarding attempts per request. Defaults to 2.
+	Attempts int
+	// Backoff is the pause between attempts. Defaults to 100ms.
+	Backoff time.Duration
+	// MaxBodyBytes bounds how much of a request body is buffered for retries.
+	// Larger requests are not forwarded. Defaults to 1 MiB.
+	MaxBodyBytes int64
+}
+
+// defaultIdempotent is the default DrainRetryConfig.Idempotent predicate.
+func defaultIdempotent(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return r.Header.Get("Idempotency-Key") != ""
+}
+
+// hopHeaders are the hop-by-hop headers dropped from forwarded requests and
+// responses, as httputil.ReverseProxy does.
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// removeHopHeaders deletes hop-by-hop headers from h, including those
+// listed in its Connection header.
+func removeHopHeaders(h http.Header) {
+	for _, f := range h.Values("Connection") {
+		for _, name := range strings.Split(f, ",") {
+			if name = textproto.TrimString(name); name != "" {
+				h.Del(name)
+			}
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+// newRetryQueue returns the semaphore bounding concurrent forwards.
+func newRetryQueue(cfg *DrainRetryConfig) chan struct{} {
+	if cfg == nil || cfg.Forwarder == nil {
+		return nil
+	}
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = 64
+	}
+	return make(chan struct{}, size)
+}
+
+// forwardRejected tries to serve a rejected request through the configured
+// forwarder. It reports whether a response was written.
+func (g *Graceful) forwardRejected(w http.ResponseWriter, r *http.Request) bool {
+	cfg := g.config.DrainRetry
+	if cfg == nil || cfg.Forwarder == nil {
+		return false
+	}
+	idempotent := cfg.Idempotent
+	if idempotent == nil {
+		idempotent = defaultIdempotent
+	}
+	if !idempotent(r) {
+		return false
+	}
+
+	// Bounded queue: give up immediately when full
+	select {
+	case g.retryQueue <- struct{}{}:
+		defer func() { <-g.retryQueue }()
+	default:
+		return false
+	}
+
+	maxBody := cfg.MaxBodyBytes
+	if maxBody <= 0 {
+		maxBody = 1 << 20
+	}
+	var body []byte
+	if r.Body != nil {
+		b, err := io.ReadAll(io.LimitReader(r.Body, maxBody+1))
+		if err != nil || int64(len(b)) > maxBody {
+			return false
+		}
+		body = b
+	}
+
+	attempts := cfg.Attempts
+	if attempts <= 0 {
+		attempts = 2
+	}
+	backoff := cfg.Backoff
+	if backoff <= 0 {
+		backoff = 100 * time.Millisecond
+	}
+
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return false
+			}
+		}
+		out := r.Clone(r.Context())
+		out.RequestURI = ""
+		out.Body = io.NopCloser(bytes.NewReader(body))
+		out.ContentLength = int64(len(body))
+		out.TransferEncoding = nil
+		removeHopHeaders(out.Header)
+		if httpguts.HeaderValuesContainsToken(r.Header["Te"], "trailers") {
+			// gRPC and other trailer-aware peers still need to know
+			out.Header.Set("Te", "trailers")
+		}
+
+		resp, err := cfg.Forwarder.RoundTrip(out)
+		if err != nil {
+			g.logger.Printf("Drain retry forward attempt %d for %s %s failed: %v", i+1, r.Method, r.URL.Path, err)
+			continue
+		}
+		if resp.StatusCode >= http.StatusInternalServerError && i < attempts-1 {
+			resp.Body.Close()
+			continue
+		}
+
+		removeHopHeaders(resp.Header)
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+		resp.Body.Close()
+		return true
+	}
+	return false
+}
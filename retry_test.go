@@ -0,0 +1,283 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func newDrainingGraceful(t *testing.T, retry *DrainRetryConfig) *Graceful {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.RejectWhileDraining = true
+	cfg.DrainRetry = retry
+	g := New(&cfg)
+	g.beginDrain(time.Now().Add(time.Second))
+	return g
+}
+
+func TestRejectWhileDraining(t *testing.T) {
+	g := newDrainingGraceful(t, nil)
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not run while draining")
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+}
+
+func TestDrainRetryForwardsIdempotentRequests(t *testing.T) {
+	var calls atomic.Int32
+	fwd := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("peer unavailable")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"X-Peer": []string{"b"}},
+			Body:       io.NopCloser(strings.NewReader("from peer")),
+		}, nil
+	})
+	g := newDrainingGraceful(t, &DrainRetryConfig{Forwarder: fwd, Backoff: time.Millisecond})
+	h := g.httpMiddleware(http.NotFoundHandler())
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() != "from peer" || rr.Header().Get("X-Peer") != "b" {
+		t.Fatalf("expected forwarded response, got %d %q", rr.Code, rr.Body.String())
+	}
+	if calls.Load() != 2 {
+		t.Fatalf("expected 2 attempts, got %d", calls.Load())
+	}
+
+	// Non-idempotent requests are rejected without forwarding
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/items", strings.NewReader("x")))
+	if rr.Code != http.StatusServiceUnavailable || calls.Load() != 2 {
+		t.Fatalf("expected 503 without forwarding, got %d (%d calls)", rr.Code, calls.Load())
+	}
+}
+
+func TestDrainRetryStripsHopHeadersAndStopsOnDisconnect(t *testing.T) {
+	var calls atomic.Int32
+	fwd := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			return nil, errors.New("peer unavailable")
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header: http.Header{
+				"Connection":        []string{"X-Hop"},
+				"X-Hop":             []string{"1"},
+				"Keep-Alive":        []string{"timeout=5"},
+				"Transfer-Encoding": []string{"chunked"},
+				"X-Peer":            []string{"b"},
+			},
+			Body: io.NopCloser(strings.NewReader("from peer")),
+		}, nil
+	})
+	g := newDrainingGraceful(t, &DrainRetryConfig{Forwarder: fwd, Backoff: time.Millisecond})
+	h := g.httpMiddleware(http.NotFoundHandler())
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil))
+	if rr.Code != http.StatusOK || rr.Header().Get("X-Peer") != "b" {
+		t.Fatalf("expected forwarded response, got %d %v", rr.Code, rr.Header())
+	}
+	for _, name := range []string{"Connection", "X-Hop", "Keep-Alive", "Transfer-Encoding"} {
+		if rr.Header().Get(name) != "" {
+			t.Fatalf("expected hop-by-hop header %s to be dropped, got %v", name, rr.Header())
+		}
+	}
+
+	// A client that disconnects does not wait out the backoff
+	g.config.DrainRetry.Backoff = time.Hour
+	calls.Store(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/items", nil).WithContext(ctx))
+	if time.Since(start) > time.Second || calls.Load() != 1 {
+		t.Fatalf("expected to give up during backoff, took %v with %d attempt(s)", time.Since(start), calls.Load())
+	}
+}
+
+func TestDrainRetryStripsHopHeadersFromForwardedRequest(t *testing.T) {
+	var out http.Header
+	fwd := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		out = r.Header
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	g := newDrainingGraceful(t, &DrainRetryConfig{Forwarder: fwd})
+	h := g.httpMiddleware(http.NotFoundHandler())
+
+	req := httptest.NewRequest(http.MethodGet, "/items", nil)
+	req.Header.Set("Connection", "X-Hop")
+	req.Header.Set("X-Hop", "1")
+	req.Header.Set("Keep-Alive", "timeout=5")
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Te", "trailers, deflate")
+	req.Header.Set("X-Request-Id", "abc")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, name := range []string{"Connection", "X-Hop", "Keep-Alive", "Proxy-Authorization", "Upgrade"} {
+		if out.Get(name) != "" {
+			t.Fatalf("expected hop-by-hop header %s to be dropped, got %v", name, out)
+		}
+	}
+	if out.Get("Te") != "trailers" || out.Get("X-Request-Id") != "abc" {
+		t.Fatalf("expected Te: trailers and end-to-end headers to be kept, got %v", out)
+	}
+	if req.Header.Get("Connection") != "X-Hop" {
+		t.Fatal("expected the original request headers to be left alone")
+	}
+}
+
+func TestRejectionPolicyPerServer(t *testing.T) {
+	g := newDrainingGraceful(t, nil)
+	g.config.Rejection = RejectionPolicy{StatusCode: http.StatusTooManyRequests, Body: "slow down"}
+
+	rr := httptest.NewRecorder()
+	g.httpMiddleware(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusTooManyRequests || rr.Body.String() != "slow down" {
+		t.Fatalf("expected configured default rejection, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	internal := &http.Server{}
+	g.SetRejectionPolicy(internal, RejectionPolicy{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"X-Reason": []string{"drain"}},
+	})
+	rr = httptest.NewRecorder()
+	g.serverMiddleware(internal, http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("X-Reason") != "drain" {
+		t.Fatalf("expected per-server rejection, got %d %v", rr.Code, rr.Header())
+	}
+}
+
+func TestRejectionGRPCCode(t *testing.T) {
+	g := newDrainingGraceful(t, nil)
+	g.config.Rejection = RejectionPolicy{GRPCCode: codes.ResourceExhausted}
+
+	_, err := g.grpcUnaryInterceptor(context.Background(), nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("handler should not run while draining")
+		return nil, nil
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestRejectionGRPCCodePerServer(t *testing.T) {
+	g := newDrainingGraceful(t, nil)
+	g.config.Rejection = RejectionPolicy{GRPCCode: codes.ResourceExhausted}
+
+	// Every method reaches the handler through gracewrap's interceptor
+	unknown := grpc.UnknownServiceHandler(func(srv interface{}, stream grpc.ServerStream) error { return nil })
+	public, publicLn, err := g.ServeGRPC("127.0.0.1:0", unknown, WithRejectionPolicy(RejectionPolicy{GRPCCode: codes.Unavailable, GRPCMessage: "try another replica"}))
+	if err != nil {
+		t.Fatalf("serve public: %v", err)
+	}
+	defer public.Stop()
+	internal, internalLn, err := g.ServeGRPC("127.0.0.1:0", unknown)
+	if err != nil {
+		t.Fatalf("serve internal: %v", err)
+	}
+	defer internal.Stop()
+	g.SetGRPCRejectionPolicy(internal, RejectionPolicy{GRPCCode: codes.Aborted})
+
+	check := func(addr string) error {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		conn, err := grpc.DialContext(ctx, addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		defer conn.Close()
+		return conn.Invoke(ctx, "/svc/Method", &emptypb.Empty{}, &emptypb.Empty{})
+	}
+
+	err = check(publicLn.Addr().String())
+	if st := status.Convert(err); st.Code() != codes.Unavailable || st.Message() != "try another replica" {
+		t.Fatalf("expected public server policy, got %v", err)
+	}
+	if err := check(internalLn.Addr().String()); status.Code(err) != codes.Aborted {
+		t.Fatalf("expected internal server policy, got %v", err)
+	}
+}
+
+func TestRejectionRetryAfterJSONAndMetric(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.EnableMetrics = true
+	cfg.PrometheusRegistry = prometheus.NewRegistry()
+	cfg.RejectWhileDraining = true
+	cfg.Rejection = RejectionPolicy{
+		RetryAfter: 1500 * time.Millisecond,
+		JSONBody:   map[string]string{"error": "draining"},
+	}
+	g := New(&cfg)
+	g.beginDrain(time.Now().Add(time.Second))
+
+	rr := httptest.NewRecorder()
+	g.httpMiddleware(http.NotFoundHandler()).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "2" {
+		t.Fatalf("expected Retry-After 2, got %q", rr.Header().Get("Retry-After"))
+	}
+	if rr.Header().Get("Content-Type") != "application/json" || rr.Body.String() != `{"error":"draining"}` {
+		t.Fatalf("unexpected body %q (%s)", rr.Body.String(), rr.Header().Get("Content-Type"))
+	}
+
+	mrr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(mrr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(mrr.Body.String(), `gracewrap_requests_rejected_total{protocol="http"} 1`) {
+		t.Fatalf("expected rejected metric, got %s", mrr.Body.String())
+	}
+}
+
+func TestDrainBypassServesCanaryRequests(t *testing.T) {
+	g := newDrainingGraceful(t, nil)
+	g.config.DrainBypass = func(r *http.Request) bool { return r.Header.Get("X-Canary") == "1" }
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	canary := httptest.NewRequest(http.MethodGet, "/", nil)
+	canary.Header.Set("X-Canary", "1")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, canary)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected canary request to be served, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected normal request to be rejected, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,43 @@
+package gracewrap
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouteLabelMetrics(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.RouteLabelFunc = func(r *http.Request) string {
+		if strings.HasPrefix(r.URL.Path, "/users/") {
+			return "/users/{id}"
+		}
+		return ""
+	}
+
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/orders/42" {
+			SetRoute(r.Context(), "/orders/:id")
+		}
+	}))
+	for _, p := range []string{"/users/1", "/users/2", "/orders/42", "/unrouted"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+	}
+
+	mrr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(mrr, httptest.NewRequest("GET", "/metrics", nil))
+	body := mrr.Body.String()
+	for _, want := range []string{
+		`gracewrap_http_route_requests_total{method="GET",route="/users/{id}"} 2`,
+		`gracewrap_http_route_requests_total{method="GET",route="/orders/:id"} 1`,
+		`gracewrap_http_request_duration_seconds_count{method="GET",route="/users/{id}"} 2`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("missing %s", want)
+		}
+	}
+	if strings.Contains(body, "/unrouted") || strings.Contains(body, `route="/users/1"`) {
+		t.Error("raw URLs must not become labels")
+	}
+}
@@ -0,0 +1,122 @@
+package gracewrap
+
+import (
+	"context"
+	"path"
+	"strings"
+	"time"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// RouteDrainPolicy customizes drain behavior for requests whose path (HTTP)
+// or full method name (gRPC) matches Pattern, for services mixing fast APIs
+// with long-running work.
+type RouteDrainPolicy struct {
+	// Pattern is a path.Match pattern; a trailing "/*" matches every path
+	// under the prefix, e.g. "/export/*".
+	Pattern string
+	// Untracked requests are served without tracking or drain rejection.
+	Untracked bool
+	// CancelAfter cancels the request context this long after drain starts.
+	CancelAfter time.Duration
+	// RunToHardStop lets requests run past the drain deadline until the hard
+	// stop, instead of having their context cancelled at the drain deadline.
+	RunToHardStop bool
+}
+
+// matches reports whether p applies to name.
+func (p *RouteDrainPolicy) matches(name string) bool {
+	if prefix, ok := strings.CutSuffix(p.Pattern, "/*"); ok {
+		return name == prefix || strings.HasPrefix(name, prefix+"/")
+	}
+	ok, _ := path.Match(p.Pattern, name)
+	return ok
+}
+
+// untrackedPolicy is the policy of paths listed in Config.UntrackedPaths.
+var untrackedPolicy = &RouteDrainPolicy{Untracked: true}
+
+// routePolicy returns the first policy matching name, or nil.
+func (g *Graceful) routePolicy(name string) *RouteDrainPolicy {
+	// Health probes answer NOT_SERVING while draining instead of being
+	// rejected, and Watch streams must not hold up the drain
+	if strings.HasPrefix(name, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return untrackedPolicy
+	}
+	for _, pattern := range g.config.UntrackedPaths {
+		if (&RouteDrainPolicy{Pattern: pattern}).matches(name) {
+			return untrackedPolicy
+		}
+	}
+	for i := range g.config.RoutePolicies {
+		if p := &g.config.RoutePolicies[i]; p.matches(name) {
+			return p
+		}
+	}
+	return nil
+}
+
+// trackRoute is trackRequest with the route's drain policy applied.
+func (g *Graceful) trackRoute(ctx context.Context, p *RouteDrainPolicy, kind, method, path string) (context.Context, func()) {
+	if p == nil {
+		return g.trackRequest(ctx, kind, method, path)
+	}
+
+	stop := func() {}
+	if p.RunToHardStop {
+		ctx, stop = g.untilHardDeadline(ctx)
+	}
+	ctx, done := g.trackRequest(ctx, kind, method, path)
+	if p.CancelAfter > 0 {
+		g.cancelAfterDrain(ctx, p.CancelAfter)
+	}
+	return ctx, func() {
+		done()
+		stop()
+	}
+}
+
+// untilHardDeadline detaches ctx from cancellation at the drain deadline and
+// cancels it at the hard deadline instead. Cancellation before the drain
+// deadline (e.g. a client disconnect) still propagates.
+func (g *Graceful) untilHardDeadline(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	stopAfter := context.AfterFunc(parent, func() {
+		if !g.draining() || time.Now().Before(g.drainDeadline()) {
+			cancel()
+		}
+	})
+	go func() {
+		if g.awaitDrainDeadline(g.config.HardStopTimeout, ctx.Done()) {
+			cancel()
+		}
+	}()
+	return ctx, func() {
+		stopAfter()
+		cancel()
+	}
+}
+
+// cancelAfterDrain cancels the tracked request in ctx d after drain starts.
+func (g *Graceful) cancelAfterDrain(ctx context.Context, d time.Duration) {
+	e, ok := ctx.Value(requestEntryKey{}).(*requestEntry)
+	if !ok || e == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-g.drainStarted():
+		case <-ctx.Done():
+			return
+		}
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			g.logger.Printf("Cancelling %s %s %s: route drain policy allows %v", e.kind, e.method, e.path, d)
+			e.cancel(ErrDrainDeadlineExceeded)
+		case <-ctx.Done():
+		}
+	}()
+}
@@ -0,0 +1,105 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRouteDrainPolicyMatching(t *testing.T) {
+	p := RouteDrainPolicy{Pattern: "/export/*"}
+	for name, want := range map[string]bool{
+		"/export":         true,
+		"/export/a/b.csv": true,
+		"/exports":        false,
+		"/api/export":     false,
+	} {
+		if got := p.matches(name); got != want {
+			t.Errorf("matches(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if !(&RouteDrainPolicy{Pattern: "/pkg.Svc/*"}).matches("/pkg.Svc/Export") {
+		t.Error("expected gRPC method to match service pattern")
+	}
+}
+
+func TestRouteDrainPolicies(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.DrainTimeout = 50 * time.Millisecond
+	g.config.HardStopTimeout = 300 * time.Millisecond
+	g.config.RoutePolicies = []RouteDrainPolicy{
+		{Pattern: "/health/*", Untracked: true},
+		{Pattern: "/api/*", CancelAfter: 10 * time.Millisecond},
+		{Pattern: "/export/*", RunToHardStop: true},
+	}
+
+	type result struct {
+		path    string
+		elapsed time.Duration
+		cause   error
+	}
+	results := make(chan result, 3)
+	started := make(chan string, 3)
+	var drainStart time.Time
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- r.URL.Path
+		if r.URL.Path == "/health/ready" {
+			return
+		}
+		<-r.Context().Done()
+		results <- result{r.URL.Path, time.Since(drainStart), context.Cause(r.Context())}
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/health/ready", nil))
+	<-started
+	if g.requestsSeen() != 0 {
+		t.Fatal("untracked route should not be counted")
+	}
+
+	// Request contexts cancelled at the drain deadline, as for wrapped servers
+	base, cancel := g.untilDrainDeadline(context.Background())
+	defer cancel()
+	for _, p := range []string{"/api/items", "/export/report"} {
+		go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil).WithContext(base))
+		<-started
+	}
+
+	drainStart = time.Now()
+	go g.Shutdown()
+
+	got := map[string]result{}
+	for i := 0; i < 2; i++ {
+		r := <-results
+		got[r.path] = r
+	}
+	if api := got["/api/items"]; api.cause != ErrDrainDeadlineExceeded || api.elapsed > 45*time.Millisecond {
+		t.Fatalf("expected /api to be cancelled shortly after drain, got %+v", api)
+	}
+	if exp := got["/export/report"]; exp.elapsed < 200*time.Millisecond {
+		t.Fatalf("expected /export to run to the hard stop, got %+v", exp)
+	}
+}
+
+func TestUntrackedPathsDoNotDelayDrain(t *testing.T) {
+	g := New(nil)
+	g.config.UntrackedPaths = []string{"/metrics", "/debug/pprof/*"}
+
+	inside := make(chan int64, 2)
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inside <- g.inflightCount()
+	}))
+	for _, p := range []string{"/metrics", "/debug/pprof/heap"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+		if n := <-inside; n != 0 {
+			t.Fatalf("%s was counted as in flight (%d)", p, n)
+		}
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api", nil))
+	if n := <-inside; n != 1 {
+		t.Fatalf("expected /api to be tracked, got %d", n)
+	}
+}
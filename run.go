@@ -0,0 +1,55 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+)
+
+// Exit codes returned by Run.
+const (
+	// ExitOK means shutdown completed cleanly.
+	ExitOK = 0
+	// ExitStartupFailure means setup failed, or a server stopped serving on
+	// its own (see Errs).
+	ExitStartupFailure = 1
+	// ExitForced means shutdown was forced or requests were still in flight
+	// at the drain deadline.
+	ExitForced = 2
+)
+
+// Run is the entrypoint for a main function: it creates a Graceful
+// configured from the environment (see ConfigFromEnv) and runs setup with
+// it, as Graceful.Run does.
+//
+//	func main() {
+//		os.Exit(gracewrap.Run(func(g *gracewrap.Graceful) error {
+//			return g.WrapHTTP(&http.Server{Addr: ":8080", Handler: mux})
+//		}))
+//	}
+func Run(setup func(g *Graceful) error) int {
+	cfg := ConfigFromEnv()
+	return New(&cfg).Run(context.Background(), setup)
+}
+
+// Run calls setup to start servers and register hooks, waits for
+// termination (see Wait) and returns the process exit code: ExitOK after a
+// clean shutdown, ExitStartupFailure if setup returned an error (servers it
+// started are stopped at once) or a server failed, and ExitForced if the
+// shutdown was forced or exceeded its deadline.
+func (g *Graceful) Run(ctx context.Context, setup func(g *Graceful) error) int {
+	if err := setup(g); err != nil {
+		g.logger.Printf("Startup failed: %v", err)
+		g.ForceShutdown()
+		return ExitStartupFailure
+	}
+
+	err := g.Wait(ctx)
+	if errors.Is(err, ErrForcedStop) || errors.Is(err, ErrDrainDeadlineExceeded) {
+		return ExitForced
+	}
+	if cause := g.failure(); cause != nil {
+		g.logger.Printf("Shutdown after failure: %v", cause)
+		return ExitStartupFailure
+	}
+	return ExitOK
+}
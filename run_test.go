@@ -0,0 +1,63 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRunExitCodes(t *testing.T) {
+	newRun := func() *Graceful {
+		g := newTestGraceful(t)
+		g.config.LoadBalancerDelay = 0
+		g.config.HardStopTimeout = 0
+		g.config.DrainTimeout = 50 * time.Millisecond
+		return g
+	}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("clean", func(t *testing.T) {
+		code := newRun().Run(cancelled, func(g *Graceful) error { return nil })
+		if code != ExitOK {
+			t.Fatalf("expected %d, got %d", ExitOK, code)
+		}
+	})
+
+	t.Run("setup failure", func(t *testing.T) {
+		g := newRun()
+		code := g.Run(context.Background(), func(g *Graceful) error { return errors.New("bind failed") })
+		if code != ExitStartupFailure {
+			t.Fatalf("expected %d, got %d", ExitStartupFailure, code)
+		}
+		if g.Ready() {
+			t.Fatal("expected servers to be stopped after a setup failure")
+		}
+	})
+
+	t.Run("serve failure", func(t *testing.T) {
+		code := newRun().Run(context.Background(), func(g *Graceful) error {
+			g.Fail(errors.New("listener lost"))
+			return nil
+		})
+		if code != ExitStartupFailure {
+			t.Fatalf("expected %d, got %d", ExitStartupFailure, code)
+		}
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		code := newRun().Run(cancelled, func(g *Graceful) error {
+			_, _ = g.trackRequest(context.Background(), "http", "GET", "/stuck")
+			// Holds the drain until its deadline has passed
+			g.OnShutdown("slow", func(ctx context.Context) error {
+				<-ctx.Done()
+				return nil
+			})
+			return nil
+		})
+		if code != ExitForced {
+			t.Fatalf("expected %d, got %d", ExitForced, code)
+		}
+	})
+}
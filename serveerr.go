@@ -0,0 +1,38 @@
+package gracewrap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// Errs returns a channel receiving the error of every server that stopped
+// serving on its own (port conflict, TLS failure, listener error) rather
+// than because of shutdown. Each such failure also starts graceful shutdown
+// through Fail, so Wait returns the first one; the process exits instead
+// of running half-alive.
+func (g *Graceful) Errs() <-chan error {
+	return g.errs
+}
+
+// serveFailed handles err returned by a server's Serve loop.
+func (g *Graceful) serveFailed(info ServerInfo, addr net.Addr, err error) {
+	if err == nil || errors.Is(err, http.ErrServerClosed) || errors.Is(err, grpc.ErrServerStopped) || g.draining() {
+		return
+	}
+	where := ""
+	if addr != nil {
+		where = " on " + addr.String()
+	}
+	err = fmt.Errorf("gracewrap: %s server %q%s stopped: %w", info.Kind, info.Name, where, err)
+	g.logger.Printf("%v", err)
+
+	select {
+	case g.errs <- err:
+	default:
+	}
+	g.Fail(err)
+}
@@ -0,0 +1,59 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServeErrorStopsProcess(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln.Close() // Serve fails at once, like a listener lost right after start
+	if err := g.WrapGRPC(g.NewGRPCServer(), ln); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-g.Errs():
+		if !strings.Contains(err.Error(), `grpc server "grpc" on `+ln.Addr().String()) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve failure not reported on Errs")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := g.Wait(ctx); err == nil || ctx.Err() != nil {
+		t.Fatalf("expected Wait to return the serve failure, got %v", err)
+	}
+}
+
+func TestServeErrorIgnoredDuringShutdown(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	if _, _, err := g.ServeGRPC("127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := g.ListenAndWrapHTTP("127.0.0.1:0", nil); err != nil {
+		t.Fatal(err)
+	}
+	g.Shutdown()
+
+	select {
+	case err := <-g.Errs():
+		t.Fatalf("unexpected serve failure after shutdown: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
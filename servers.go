@@ -0,0 +1,139 @@
+package gracewrap
+
+import (
+	"fmt"
+	"net"
+	"time"
+)
+
+// ServerInfo describes a server tracked by gracewrap.
+type ServerInfo struct {
+	// Name identifies the server in logs and metrics: the name given with
+	// SetServerName or WithServerName, or else its kind, suffixed with a
+	// sequence number from the second server of that kind on ("http",
+	// "http-2", "grpc").
+	Name string
+	// Kind is the shutdown node the server belongs to (NodeHTTP, NodeGRPC).
+	Kind string
+	// Addr is the bound address, with the actual port for ":0".
+	Addr net.Addr
+	// Addrs lists every bound address of a server served on several
+	// listeners (see WrapHTTPListeners); Addr is the first of them.
+	Addrs []net.Addr
+
+	ref interface{} // the *http.Server or *grpc.Server
+}
+
+// addServer records a started server and returns its info.
+func (g *Graceful) addServer(kind string, ref interface{}, addrs ...net.Addr) ServerInfo {
+	g.servers.mu.Lock()
+	defer g.servers.mu.Unlock()
+
+	n := 1
+	for _, s := range g.servers.list {
+		if s.Kind == kind {
+			n++
+		}
+	}
+	info := ServerInfo{Name: kind, Kind: kind, Addrs: addrs, ref: ref}
+	if len(addrs) > 0 {
+		info.Addr = addrs[0]
+	}
+	if name, ok := g.servers.names[ref]; ok {
+		info.Name = name
+	} else if n > 1 {
+		info.Name = fmt.Sprintf("%s-%d", kind, n)
+	}
+	g.servers.list = append(g.servers.list, info)
+	return info
+}
+
+// SetServerName names server (an *http.Server, *grpc.Server or
+// HTTP3Server) for logs, metrics and Addr, e.g. "public-api" and
+// "internal" in a process serving both. It may be called before or after the
+// server is wrapped.
+func (g *Graceful) SetServerName(server interface{}, name string) {
+	g.servers.mu.Lock()
+	defer g.servers.mu.Unlock()
+	if g.servers.names == nil {
+		g.servers.names = make(map[interface{}]string)
+	}
+	g.servers.names[server] = name
+	for i := range g.servers.list {
+		if g.servers.list[i].ref == server {
+			g.servers.list[i].Name = name
+		}
+	}
+}
+
+// WithServerName names the server created by NewGRPCServer or ServeGRPC
+// (see SetServerName).
+func WithServerName(name string) GRPCOption {
+	return GRPCOption{apply: func(o *grpcOptions) { o.name = name }}
+}
+
+// serverName returns the name of server, or its given name if it is not
+// tracked yet.
+func (g *Graceful) serverName(server interface{}) string {
+	g.servers.mu.Lock()
+	defer g.servers.mu.Unlock()
+	for _, s := range g.servers.list {
+		if s.ref == server {
+			return s.Name
+		}
+	}
+	return g.servers.names[server]
+}
+
+// observeServerStop records how long the server took to stop, labelled by
+// its name, and adds it to the shutdown report.
+func (g *Graceful) observeServerStop(server interface{}, kind string, start time.Time, err error) {
+	name, d := g.serverName(server), time.Since(start)
+	if g.metrics != nil {
+		g.metrics.observeServerStop(name, kind, d)
+	}
+	res := ServerResult{Name: name, Kind: kind, Duration: d}
+	if err != nil {
+		res.Error = err.Error()
+	}
+	g.updateReport(func(r *ShutdownReport) { r.Servers = append(r.Servers, res) })
+}
+
+// Servers returns the tracked servers in start order.
+func (g *Graceful) Servers() []ServerInfo {
+	g.servers.mu.Lock()
+	defer g.servers.mu.Unlock()
+	return append([]ServerInfo(nil), g.servers.list...)
+}
+
+// Addr returns the bound address of the named server (see ServerInfo.Name),
+// or nil if there is none. Addresses are known as soon as the Wrap or Serve
+// call returns, so callers binding ":0" can discover the port without racing
+// the serve goroutine.
+func (g *Graceful) Addr(name string) net.Addr {
+	for _, s := range g.Servers() {
+		if s.Name == name {
+			return s.Addr
+		}
+	}
+	return nil
+}
+
+// Addrs returns the bound address of every tracked listener, in start order.
+func (g *Graceful) Addrs() []net.Addr {
+	var out []net.Addr
+	for _, s := range g.Servers() {
+		out = append(out, s.Addrs...)
+	}
+	return out
+}
+
+// serverAddr returns the bound address of server, or nil.
+func (g *Graceful) serverAddr(server interface{}) net.Addr {
+	for _, s := range g.Servers() {
+		if s.ref == server {
+			return s.Addr
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package gracewrap
+
+import (
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestAddrReturnsBoundPorts(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	defer g.Shutdown()
+
+	for i := 0; i < 2; i++ {
+		srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+		if err := g.WrapHTTP(srv); err != nil {
+			t.Fatalf("wrap http: %v", err)
+		}
+	}
+	if _, _, err := g.ServeGRPC("127.0.0.1:0"); err != nil {
+		t.Fatalf("serve grpc: %v", err)
+	}
+
+	for _, name := range []string{"http", "http-2", "grpc"} {
+		addr := g.Addr(name)
+		if addr == nil || addr.(*net.TCPAddr).Port == 0 {
+			t.Fatalf("%s: expected a bound port, got %v", name, addr)
+		}
+	}
+	if g.Addr("missing") != nil {
+		t.Fatal("expected nil for an unknown server")
+	}
+
+	// The address is usable as soon as WrapHTTP returns
+	resp, err := http.Get("http://" + g.Addr("http").String())
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+
+	// Bind errors surface synchronously
+	taken := &http.Server{Addr: g.Addr("http").String(), Handler: http.NotFoundHandler()}
+	if err := g.WrapHTTP(taken); err == nil {
+		t.Fatal("expected bind error for an address in use")
+	}
+	if n := len(g.Servers()); n != 3 {
+		t.Fatalf("expected 3 servers, got %d", n)
+	}
+}
+
+func TestWrapHTTPListenersServesAll(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	var listeners []net.Listener
+	for i := 0; i < 2; i++ {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		listeners = append(listeners, ln)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})}
+	if err := g.WrapHTTPListeners(srv, listeners...); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.WrapHTTPListeners(srv); err == nil {
+		t.Fatal("expected an error without listeners")
+	}
+
+	addrs := g.Addrs()
+	if len(addrs) != 2 || len(g.Servers()) != 1 {
+		t.Fatalf("expected one server on two addresses, got %v", g.Servers())
+	}
+	for _, addr := range addrs {
+		resp, err := http.Get("http://" + addr.String())
+		if err != nil {
+			t.Fatalf("get %s: %v", addr, err)
+		}
+		resp.Body.Close()
+	}
+
+	g.Shutdown()
+	for _, addr := range addrs {
+		if _, err := http.Get("http://" + addr.String()); err == nil {
+			t.Fatalf("expected %s to be closed after shutdown", addr)
+		}
+	}
+}
+
+func TestServerNames(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	public := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	g.SetServerName(public, "public-api")
+	if err := g.WrapHTTP(public); err != nil {
+		t.Fatalf("wrap http: %v", err)
+	}
+	if _, _, err := g.ServeGRPC("127.0.0.1:0", WithServerName("internal")); err != nil {
+		t.Fatalf("serve grpc: %v", err)
+	}
+	admin := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+	if err := g.WrapHTTP(admin); err != nil {
+		t.Fatalf("wrap http: %v", err)
+	}
+	g.SetServerName(admin, "admin")
+
+	for _, name := range []string{"public-api", "internal", "admin"} {
+		if g.Addr(name) == nil {
+			t.Fatalf("%s: expected a named server", name)
+		}
+	}
+
+	g.Shutdown()
+	assertMetric(t, g, `gracewrap_server_stop_duration_seconds{kind="grpc",server="internal"}`)
+	assertMetric(t, g, `gracewrap_server_stop_duration_seconds{kind="http",server="public-api"}`)
+}
@@ -0,0 +1,63 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestMaxInflightSheds(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.MaxInflight = 2
+	g.config.MaxInflightDuringDrain = 1
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+	}))
+	for i := 0; i < 2; i++ {
+		go h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/slow", nil))
+		<-started
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/fast", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the limit, got %d", rec.Code)
+	}
+	_, err := g.grpcUnaryInterceptor(context.Background(), nil, nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+
+	// One slow request left, but the drain limit is lower
+	release <- struct{}{}
+	for g.inflightCount() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	g.beginDrain(time.Now().Add(time.Second))
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/fast", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 over the drain limit, got %d", rec.Code)
+	}
+	close(release)
+
+	mrr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(mrr, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(mrr.Body.String(), `gracewrap_requests_shed_total{protocol="http"} 2`) {
+		t.Fatal("expected shed requests to be counted")
+	}
+}
@@ -3,132 +3,314 @@ package gracewrap
 import (
 	"context"
 	"net/http"
-	"sync"
 	"time"
-
-	"google.golang.org/grpc"
 )
 
 // shutdown performs graceful shutdown of all tracked services.
 func (g *Graceful) shutdown() {
-	g.stopOnce.Do(func() {
-		start := time.Now()
+	cancels := g.drainCancels()
+	g.stopMu.Lock()
+	defer g.stopMu.Unlock()
+	select {
+	case <-g.done:
+		return
+	default:
+	}
+	if g.drainCancels() != cancels {
+		// The drain this call was waiting on has been cancelled
+		return
+	}
 
-		// Update metrics
-		if g.metrics != nil {
-			g.metrics.incShutdowns()
-		}
+	start := time.Now()
 
-		// 1. Mark as not ready to stop new traffic
-		g.setReady(false)
-		g.logger.Printf("Marked as not ready; health checks will now return 503")
+	// Update metrics
+	if g.metrics != nil {
+		g.metrics.incShutdowns()
+	}
 
-		// 2. Wait for load balancers/service mesh to notice readiness change
-		if g.config.LoadBalancerDelay > 0 {
-			g.logger.Printf("Waiting %v for load balancers to stop routing traffic...", g.config.LoadBalancerDelay)
-			time.Sleep(g.config.LoadBalancerDelay)
+	lbDelay, hardStop := g.config.LoadBalancerDelay, g.config.HardStopTimeout
+	if g.config.SkipDelaysWithoutTraffic && g.requestsSeen() == 0 {
+		g.logger.Printf("No traffic observed since start; skipping load balancer delay and hard stop wait")
+		lbDelay, hardStop = 0, 0
+	} else {
+		// Spread drain start and the LB delay across replicas
+		if d := jitter(g.config.DrainStartJitter, "drain"); d > 0 && !g.wasCut() {
+			g.logger.Printf("Delaying drain start by %v (jitter)", d)
+			jitterStart := time.Now()
+			g.pause(d)
+			g.recordPhase("jitter", jitterStart)
 		}
+		lbDelay += jitter(g.config.LoadBalancerDelayJitter, "lb")
+	}
 
-		// 3. Graceful shutdown with timeout (HTTP servers will close their own listeners)
-		drainDeadline := time.Now().Add(g.config.DrainTimeout)
-		g.gracefulShutdown(drainDeadline)
+	// 1. Mark as not ready to stop new traffic
+	g.beginDrain(time.Now().Add(lbDelay + g.config.DrainTimeout))
+	g.setReady(false)
+	stopStats := g.recordDrainStats()
+	stopProfiles := g.captureProfiles()
+	stopAudit := func() {}
+	if g.config.ClockAudit {
+		stopAudit = g.auditClock()
+	}
+	g.cancelRequestsOverBudget()
+	g.logger.Printf("Marked as not ready; health checks will now return 503")
+	g.emit(EventDrainStarted, "readiness set to false")
+	g.goAwayH2C()
+	g.goAwayGRPC()
+	if g.config.DisableKeepAlivesOnDrain {
+		g.setKeepAlives(false)
+	}
+	g.reapIdleConns()
+	pollCtx, cancelPollers := g.untilDrainDeadline(context.Background())
+	defer cancelPollers()
+	g.stopPollers(pollCtx)
+	g.drainEnvoy(pollCtx)
 
-		// 4. Wait for in-flight requests to complete
-		ok := g.waitForInflight(drainDeadline)
-		if !ok {
-			g.logger.Printf("In-flight requests did not complete before deadline")
-		}
+	// 2. Wait for load balancers/service mesh to notice readiness change
+	cancelled := g.drainCancelled()
+	if lbDelay > 0 && !g.wasCut() {
+		g.emit(EventLoadBalancerDelay, "waiting %v", lbDelay)
+		delayStart := time.Now()
+		g.awaitLoadBalancers(lbDelay, cancelled)
+		g.recordPhase("load_balancer_delay", delayStart)
+	}
+	estimate := g.drainDeadline()
+	drainDeadline := time.Now().Add(g.config.DrainTimeout)
+	if !g.commitDrain(drainDeadline) {
+		stopAudit()
+		stopStats()
+		g.resetDrainStats()
+		stopProfiles()
+		g.resumeAfterCancel()
+		return
+	}
 
-		// 5. Final hard stop if configured
-		if g.config.HardStopTimeout > 0 {
-			g.logger.Printf("Waiting %v for final cleanup", g.config.HardStopTimeout)
-			time.Sleep(g.config.HardStopTimeout)
-		}
+	if drainDeadline.Before(estimate) {
+		// The load balancer wait was cut short; re-check request budgets
+		g.cancelRequestsOverBudget()
+	}
+
+	// 3. Graceful shutdown with timeout (HTTP servers will close their own listeners)
+	g.handoffSessions(g.cutDeadline(drainDeadline))
+	g.runDrainCallbacks()
+	g.beginStopping()
+	g.emit(EventServersStopping, "drain deadline %s", drainDeadline.Format(time.RFC3339Nano))
+	atStop := g.inflightCount()
+	stopStart := time.Now()
+	g.gracefulShutdown(drainDeadline)
+	g.recordPhase("stop_servers", stopStart)
 
-		// Update metrics
-		if g.metrics != nil {
-			g.metrics.observeShutdownDuration(time.Since(start))
+	// 4. Wait for in-flight requests to complete
+	g.emit(EventInflightWait, "%d request(s) in flight", g.inflightCount())
+	waitStart := time.Now()
+	ok := g.waitForInflight(drainDeadline) == 0
+	if !ok {
+		g.logger.Printf("In-flight requests did not complete before deadline")
+		g.emit(EventDrainTimeout, "%d request(s) still in flight", g.inflightCount())
+		g.logActiveRequests()
+		g.dumpStall()
+		hardStop -= g.handleStragglers(hardStop)
+		if hardStop < 0 {
+			hardStop = 0
 		}
+	}
+	g.recordPhase("inflight_wait", waitStart)
+	abandoned := g.inflightCount()
+	completed := atStop - abandoned
+	if completed < 0 {
+		completed = 0
+	}
+	g.updateReport(func(r *ShutdownReport) {
+		r.InflightAtStop, r.InflightCompleted, r.InflightAbandoned = atStop, completed, abandoned
+	})
+	drainUsed := time.Since(drainDeadline.Add(-g.config.DrainTimeout))
+	g.updateReport(func(r *ShutdownReport) { r.DrainDuration = drainUsed })
+	if g.config.DrainHistoryFile != "" {
+		if err := g.recordDrain(drainUsed); err != nil {
+			g.logger.Printf("Recording drain history failed: %v", err)
+		}
+	}
+	g.closePools(g.cutDeadline(drainDeadline))
+	if !g.wasCut() && !g.waitForTasks(drainDeadline) {
+		g.logger.Printf("Periodic tasks did not complete before deadline")
+	}
+	g.saveCaches()
+
+	// 5. Final hard stop if configured
+	if hardStop > 0 && !g.wasCut() {
+		g.logger.Printf("Waiting %v for final cleanup", hardStop)
+		g.emit(EventHardStop, "waiting %v", hardStop)
+		hardStart := time.Now()
+		g.pause(hardStop)
+		g.recordPhase("hard_stop", hardStart)
+	}
 
-		g.logger.Printf("Graceful shutdown completed")
+	stopAudit()
+	slices := stopStats()
+	profiles := stopProfiles()
+	forced := g.cut.forced.Load()
+	g.updateReport(func(r *ShutdownReport) {
+		r.DrainSlices = slices
+		r.Profiles = append(r.Profiles, profiles...)
+		r.DeadlineExceeded = !ok || (g.wasCut() && !forced)
+		r.Forced = forced
+		r.Duration = time.Since(start)
 	})
+
+	// Update metrics
+	if g.metrics != nil {
+		g.metrics.observeShutdownDuration(time.Since(start))
+	}
+	g.writeReport()
+
+	close(g.done)
+	g.closeProbes()
+	g.logger.Printf("Graceful shutdown completed")
+	g.emit(EventShutdownCompleted, "took %v", time.Since(start).Round(time.Millisecond))
 }
 
-// gracefulShutdown shuts down all servers gracefully within the deadline.
+// awaitLoadBalancers waits d for load balancers to notice the readiness
+// change, returning early if the shutdown is cut short or stop is closed.
+func (g *Graceful) awaitLoadBalancers(d time.Duration, stop <-chan struct{}) {
+	g.logger.Printf("Waiting %v for load balancers to stop routing traffic...", d)
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-g.cut.ch:
+	case <-stop:
+	}
+}
+
+// gracefulShutdown shuts down all servers and runs shutdown hooks within the
+// deadline, honoring the ordering declared with After.
 func (g *Graceful) gracefulShutdown(deadline time.Time) {
-	var wg sync.WaitGroup
+	ctx, cancel := context.WithDeadline(context.Background(), g.cutDeadline(deadline))
+	defer cancel()
+	go func() {
+		select {
+		case <-g.cut.ch:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	nodes := make(map[string][]stopFunc)
+	groups := make(map[string][]string)
 
 	// Shutdown HTTP servers
 	for _, server := range g.httpServers {
-		wg.Add(1)
-		go func(srv *http.Server) {
-			defer wg.Done()
-			ctx, cancel := context.WithDeadline(context.Background(), deadline)
-			defer cancel()
-
-			if err := srv.Shutdown(ctx); err != nil {
-				g.logger.Printf("HTTP server shutdown error: %v", err)
-			} else {
-				g.logger.Printf("HTTP server shutdown completed")
-			}
-		}(server)
+		srv := server
+		node := g.serverNode(srv, NodeHTTP, groups)
+		nodes[node] = append(nodes[node], func(ctx context.Context) {
+			start := time.Now()
+			g.observeServerStop(srv, NodeHTTP, start, g.stopHTTP(ctx, srv))
+		})
 	}
 
 	// Shutdown gRPC servers
 	for _, server := range g.grpcServers {
-		wg.Add(1)
-		go func(srv *grpc.Server) {
-			defer wg.Done()
-
-			// Start graceful stop in background
-			done := make(chan struct{})
-			go func() {
-				srv.GracefulStop()
-				close(done)
-			}()
-
-			// Force stop if deadline exceeded
-			timer := time.NewTimer(time.Until(deadline))
-			defer timer.Stop()
-
-			select {
-			case <-done:
-				g.logger.Printf("gRPC server graceful shutdown completed")
-			case <-timer.C:
-				g.logger.Printf("gRPC server deadline reached; forcing stop")
-				srv.Stop()
-			}
-		}(server)
-	}
-
-	// Wait for all servers to shutdown
-	wg.Wait()
-}
+		srv := server
+		node := g.serverNode(srv, NodeGRPC, groups)
+		nodes[node] = append(nodes[node], func(ctx context.Context) {
+			start := time.Now()
+			g.observeServerStop(srv, NodeGRPC, start, g.stopGRPC(ctx, srv))
+		})
+	}
 
-// waitForInflight waits for all in-flight requests to complete.
-func (g *Graceful) waitForInflight(deadline time.Time) bool {
-	g.inflight.mu.Lock()
-	defer g.inflight.mu.Unlock()
+	// Shutdown HTTP/3 servers
+	for _, server := range g.http3Servers {
+		srv := server
+		node := g.serverNode(srv, NodeHTTP3, groups)
+		nodes[node] = append(nodes[node], func(ctx context.Context) {
+			start := time.Now()
+			g.observeServerStop(srv, NodeHTTP3, start, g.stopHTTP3(ctx, srv))
+		})
+	}
 
-	for g.inflight.n > 0 {
-		now := time.Now()
-		if now.After(deadline) {
-			return false
+	// Close WebSocket connections
+	nodes[NodeWebsocket] = append(nodes[NodeWebsocket], g.closeWebsockets)
+
+	// Shutdown hooks
+	g.hookNodes(nodes, deadline)
+
+	g.runOrdered(ctx, nodes, groups)
+}
+
+// stopHTTP shuts srv down within ctx, or closes it at once when shutdown is
+// forced.
+func (g *Graceful) stopHTTP(ctx context.Context, srv *http.Server) error {
+	defer g.releaseBaseContexts(srv)
+	name := g.serverName(srv)
+	if g.cut.forced.Load() {
+		err := srv.Close()
+		if err != nil {
+			g.logger.Printf("HTTP server %q close error: %v", name, err)
+		} else {
+			g.logger.Printf("HTTP server %q closed", name)
 		}
+		return err
+	}
+	if g.config.LivenessPath != "" {
+		go g.keepLivenessProbes(srv)
+	}
+	err := srv.Shutdown(ctx)
+	if err != nil {
+		g.logger.Printf("HTTP server %q shutdown error: %v", name, err)
+	} else {
+		g.logger.Printf("HTTP server %q shutdown completed", name)
+	}
+	return err
+}
 
-		// Calculate wait time
-		wait := time.Second
-		if now.Add(wait).After(deadline) {
-			wait = time.Until(deadline)
+// serverNode returns the shutdown node server stops under: its own name if
+// it was given one (see SetServerName), recorded as a member of its kind's
+// group, or the group itself.
+func (g *Graceful) serverNode(server interface{}, kind string, groups map[string][]string) string {
+	g.servers.mu.Lock()
+	name := g.servers.names[server]
+	g.servers.mu.Unlock()
+	if name == "" || name == kind {
+		return kind
+	}
+	groups[kind] = append(groups[kind], name)
+	return name
+}
+
+// waitForInflight waits for all in-flight requests to complete, until the
+// deadline passes or the shutdown is cut short, and returns how many were
+// still in flight (abandoned) when it gave up.
+func (g *Graceful) waitForInflight(deadline time.Time) int64 {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for {
+		g.inflight.mu.Lock()
+		n, idle := g.inflight.n, g.inflight.idle
+		g.inflight.mu.Unlock()
+		if n == 0 {
+			return 0
 		}
 
-		// Wait with timeout
-		timer := time.NewTimer(wait)
-		g.inflight.cv.Wait() // This will be woken up by dec() when count reaches 0
-		timer.Stop()
+		select {
+		case <-idle:
+			// Re-check: a new request may have started since
+		case <-timer.C:
+			return g.inflightCount()
+		case <-g.cut.ch:
+			return g.inflightCount()
+		}
 	}
+}
 
-	return true
+// setKeepAlives enables or disables keep-alives on all tracked HTTP servers.
+func (g *Graceful) setKeepAlives(enabled bool) {
+	for _, srv := range g.httpServers {
+		srv.SetKeepAlivesEnabled(enabled)
+	}
+	if !enabled && len(g.httpServers) > 0 {
+		g.logger.Printf("Disabled HTTP keep-alives")
+	}
 }
 
 // setReady sets the readiness status.
@@ -136,6 +318,7 @@ func (g *Graceful) setReady(ready bool) {
 	g.readyMu.Lock()
 	g.ready = ready
 	g.readyMu.Unlock()
+	g.syncHealth(ready)
 
 	// Update metrics
 	if g.metrics != nil {
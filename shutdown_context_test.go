@@ -0,0 +1,46 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdownContextCompletes(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	if err := g.ShutdownWithTimeout(time.Second); err != nil {
+		t.Fatalf("expected clean shutdown, got %v", err)
+	}
+	// Later calls report the completed shutdown
+	if err := g.ShutdownContext(context.Background()); err != nil {
+		t.Fatalf("expected nil after completion, got %v", err)
+	}
+}
+
+func TestShutdownContextCutsWaitsShort(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 5 * time.Second
+	g.config.DrainTimeout = 10 * time.Second
+	g.config.HardStopTimeout = 5 * time.Second
+
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/slow")
+	defer done()
+
+	start := time.Now()
+	err := g.ShutdownWithTimeout(100 * time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Fatalf("shutdown took %v despite its deadline", d)
+	}
+	select {
+	case <-g.done:
+	default:
+		t.Fatal("expected shutdown to have completed")
+	}
+}
@@ -0,0 +1,43 @@
+package gracewrap
+
+import (
+	"os"
+	"os/signal"
+)
+
+// OnSignal runs fn each time sig arrives, e.g. SIGUSR1 to enter maintenance
+// mode or SIGUSR2 to reopen log files. Handlers for a signal run in
+// registration order, one signal at a time, and stop once shutdown has
+// completed. Registering a shutdown signal (see Config.ShutdownSignals) adds
+// an action without stopping it from starting shutdown.
+func (g *Graceful) OnSignal(sig os.Signal, fn func()) {
+	g.signals.mu.Lock()
+	defer g.signals.mu.Unlock()
+	if g.signals.ch == nil {
+		g.signals.ch = make(chan os.Signal, 4)
+		g.signals.handlers = make(map[os.Signal][]func())
+		go g.dispatchSignals(g.signals.ch)
+	}
+	g.signals.handlers[sig] = append(g.signals.handlers[sig], fn)
+	signal.Notify(g.signals.ch, sig)
+}
+
+// dispatchSignals runs the handlers of each signal received on ch until
+// shutdown completes.
+func (g *Graceful) dispatchSignals(ch chan os.Signal) {
+	defer signal.Stop(ch)
+	for {
+		select {
+		case sig := <-ch:
+			g.signals.mu.Lock()
+			handlers := append([]func(){}, g.signals.handlers[sig]...)
+			g.signals.mu.Unlock()
+			g.logger.Printf("Received signal %v; running %d action(s)", sig, len(handlers))
+			for _, fn := range handlers {
+				fn()
+			}
+		case <-g.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package gracewrap
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOnSignal(t *testing.T) {
+	g := newTestGraceful(t)
+
+	got := make(chan int, 2)
+	g.OnSignal(syscall.SIGHUP, func() { got <- 1 })
+	g.OnSignal(syscall.SIGHUP, func() { got <- 2 })
+
+	p, _ := os.FindProcess(os.Getpid())
+	_ = p.Signal(syscall.SIGHUP)
+
+	for want := 1; want <= 2; want++ {
+		select {
+		case n := <-got:
+			if n != want {
+				t.Fatalf("expected handler %d, got %d", want, n)
+			}
+		case <-time.After(500 * time.Millisecond):
+			t.Skip("signal delivery not available in this environment")
+		}
+	}
+	if !g.Ready() {
+		t.Fatal("a signal action should not start shutdown")
+	}
+}
+
+func TestShutdownSignals(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.ShutdownSignals = []os.Signal{syscall.SIGHUP}
+
+	done := make(chan struct{})
+	go func() {
+		_ = g.Wait(context.Background())
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	p, _ := os.FindProcess(os.Getpid())
+	_ = p.Signal(syscall.SIGHUP)
+
+	select {
+	case <-done:
+	case <-time.After(500 * time.Millisecond):
+		t.Skip("signal delivery not available in this environment")
+	}
+}
@@ -0,0 +1,169 @@
+package gracewrap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ShutdownPlan is what a shutdown would do with the current configuration
+// and registrations (see SimulateShutdown).
+type ShutdownPlan struct {
+	// Phases are the shutdown phases in order, with how long each may take.
+	Phases []PlannedPhase `json:"phases"`
+	// Stages is the stop order: each stage lists the nodes (servers, server
+	// groups and hooks) that stop concurrently once the nodes they are
+	// ordered after, in earlier stages, have stopped.
+	Stages [][]string `json:"stages"`
+	// Servers is how each tracked server will be stopped.
+	Servers []PlannedServer `json:"servers,omitempty"`
+	// HookBudgets is the timeout each shutdown hook will get, by name.
+	HookBudgets map[string]time.Duration `json:"hook_budgets,omitempty"`
+	// WorstCase is the longest the shutdown can take, with every jitter at
+	// its maximum and every wait running to its deadline. Keep it below the
+	// pod's terminationGracePeriodSeconds.
+	WorstCase time.Duration `json:"worst_case"`
+}
+
+// PlannedPhase is a shutdown phase and the longest it can take.
+type PlannedPhase struct {
+	Name string        `json:"name"`
+	Max  time.Duration `json:"max"`
+}
+
+// PlannedServer is how a server will be stopped.
+type PlannedServer struct {
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Node is the shutdown node the server stops under: its name, or its
+	// kind's group when unnamed.
+	Node string `json:"node"`
+	// Deadline is how long in-flight requests get before the server is
+	// stopped outright; zero with Immediate.
+	Deadline  time.Duration `json:"deadline"`
+	Immediate bool          `json:"immediate,omitempty"`
+}
+
+// SimulateShutdown walks the shutdown plan without stopping anything: the
+// phases and their durations, the order servers and hooks stop in, each
+// server's deadline and each hook's budget. The plan is logged and returned,
+// so it can be checked in staging (or asserted in tests) before a rollout
+// relies on it.
+func (g *Graceful) SimulateShutdown() *ShutdownPlan {
+	drain := g.config.DrainTimeout
+	plan := &ShutdownPlan{
+		Phases: []PlannedPhase{
+			{Name: "jitter", Max: g.config.DrainStartJitter},
+			{Name: "load_balancer_delay", Max: g.config.LoadBalancerDelay + g.config.LoadBalancerDelayJitter},
+			{Name: "drain", Max: drain},
+			{Name: "hard_stop", Max: g.config.HardStopTimeout},
+		},
+	}
+	for _, p := range plan.Phases {
+		plan.WorstCase += p.Max
+	}
+
+	nodes := make(map[string][]stopFunc)
+	groups := make(map[string][]string)
+	for _, srv := range g.httpServers {
+		plan.Servers = append(plan.Servers, g.planServer(srv, NodeHTTP, groups, nodes, drain))
+	}
+	for _, srv := range g.grpcServers {
+		ps := g.planServer(srv, NodeGRPC, groups, nodes, drain)
+		switch p := g.grpcStopPolicy(srv); {
+		case p.Mode == StopImmediately:
+			ps.Deadline, ps.Immediate = 0, true
+		case p.DrainTimeout > 0 && p.DrainTimeout < drain:
+			ps.Deadline = p.DrainTimeout
+		}
+		plan.Servers = append(plan.Servers, ps)
+	}
+	for _, srv := range g.http3Servers {
+		plan.Servers = append(plan.Servers, g.planServer(srv, NodeHTTP3, groups, nodes, drain))
+	}
+	nodes[NodeWebsocket] = nil
+
+	g.order.mu.Lock()
+	hooks := append([]hook(nil), g.order.hooks...)
+	g.order.mu.Unlock()
+	for _, h := range hooks {
+		nodes[h.name] = nil
+	}
+	plan.HookBudgets = g.hookBudgets(hooks, drain)
+
+	plan.Stages = stages(nodes, g.nodeDeps(nodes, groups))
+	g.logger.Printf("%s", plan)
+	return plan
+}
+
+// planServer records the node server stops under and returns its plan with
+// the default deadline.
+func (g *Graceful) planServer(server interface{}, kind string, groups map[string][]string, nodes map[string][]stopFunc, deadline time.Duration) PlannedServer {
+	node := g.serverNode(server, kind, groups)
+	nodes[node] = nil
+	return PlannedServer{Name: g.serverName(server), Kind: kind, Node: node, Deadline: deadline}
+}
+
+// stages groups nodes into the order they stop in: each stage holds the
+// nodes whose dependencies are all in earlier stages.
+func stages(nodes map[string][]stopFunc, deps map[string][]string) [][]string {
+	placed := make(map[string]bool, len(nodes))
+	var out [][]string
+	for len(placed) < len(nodes) {
+		var stage []string
+		for name := range nodes {
+			if placed[name] {
+				continue
+			}
+			ready := true
+			for _, dep := range deps[name] {
+				if _, ok := nodes[dep]; ok && !placed[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				stage = append(stage, name)
+			}
+		}
+		if len(stage) == 0 {
+			// Unreachable: After rejects cycles
+			break
+		}
+		sort.Strings(stage)
+		for _, name := range stage {
+			placed[name] = true
+		}
+		out = append(out, stage)
+	}
+	return out
+}
+
+// String renders the plan for logs.
+func (p *ShutdownPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Shutdown plan (worst case %v):", p.WorstCase)
+	for _, ph := range p.Phases {
+		fmt.Fprintf(&b, "\n  phase %s: up to %v", ph.Name, ph.Max)
+	}
+	for i, st := range p.Stages {
+		fmt.Fprintf(&b, "\n  stage %d: %s", i+1, strings.Join(st, ", "))
+	}
+	for _, s := range p.Servers {
+		if s.Immediate {
+			fmt.Fprintf(&b, "\n  %s server %q (node %s): stopped immediately", s.Kind, s.Name, s.Node)
+		} else {
+			fmt.Fprintf(&b, "\n  %s server %q (node %s): drain deadline %v", s.Kind, s.Name, s.Node, s.Deadline)
+		}
+	}
+	names := make([]string, 0, len(p.HookBudgets))
+	for name := range p.HookBudgets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "\n  hook %q: budget %v", name, p.HookBudgets[name])
+	}
+	return b.String()
+}
@@ -0,0 +1,68 @@
+package gracewrap
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSimulateShutdown(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = time.Second
+	g.config.LoadBalancerDelayJitter = 500 * time.Millisecond
+	g.config.DrainTimeout = 20 * time.Second
+	g.config.HardStopTimeout = 5 * time.Second
+
+	public, publicAddr, err := g.ListenAndWrapHTTP("127.0.0.1:0", http.NotFoundHandler())
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.SetServerName(public, "public")
+	internal := g.NewGRPCServer()
+	g.SetServerName(internal, "internal")
+	g.SetGRPCStopPolicy(internal, GRPCStopPolicy{DrainTimeout: 5 * time.Second})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.WrapGRPC(internal, ln); err != nil {
+		t.Fatal(err)
+	}
+	g.OnShutdownTimeout("flush", 3*time.Second, func(ctx context.Context) error { return nil })
+	if err := g.ShutdownOrder("public", "internal", "flush"); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := g.SimulateShutdown()
+
+	if want := 26500 * time.Millisecond; plan.WorstCase != want {
+		t.Fatalf("expected worst case %v, got %v", want, plan.WorstCase)
+	}
+	want := [][]string{{"public", NodeWebsocket}, {NodeHTTP, "internal"}, {"flush", NodeGRPC}}
+	if !reflect.DeepEqual(plan.Stages, want) {
+		t.Fatalf("expected stages %v, got %v", want, plan.Stages)
+	}
+	deadlines := map[string]time.Duration{}
+	for _, s := range plan.Servers {
+		deadlines[s.Name] = s.Deadline
+	}
+	if deadlines["public"] != 20*time.Second || deadlines["internal"] != 5*time.Second {
+		t.Fatalf("unexpected server deadlines %v", deadlines)
+	}
+	if plan.HookBudgets["flush"] != 3*time.Second {
+		t.Fatalf("unexpected hook budgets %v", plan.HookBudgets)
+	}
+
+	// Nothing was stopped
+	if !g.Ready() {
+		t.Fatal("expected still ready after a simulation")
+	}
+	resp, err := http.Get("http://" + publicAddr)
+	if err != nil {
+		t.Fatalf("expected public server still serving: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,20 @@
+package gracewrap
+
+import "context"
+
+// WithDrainDeadline returns a context for a database/sql call (or any other
+// I/O) that cannot outlive the drain:
+//
+//	ctx, cancel := g.WithDrainDeadline(r.Context())
+//	defer cancel()
+//	rows, err := db.QueryContext(ctx, query)
+//
+// Queries started once the servers are stopping get the remaining budget as
+// their deadline, so drivers can set server-side timeouts from it. Queries
+// started earlier are cancelled once the drain deadline passes.
+func (g *Graceful) WithDrainDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
+	if deadline, ok := g.committedDrainDeadline(); ok {
+		return context.WithDeadlineCause(ctx, deadline, ErrDrainDeadlineExceeded)
+	}
+	return g.untilDrainDeadline(ctx)
+}
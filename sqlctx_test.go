@@ -0,0 +1,47 @@
+package gracewrap
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithDrainDeadline(t *testing.T) {
+	g := New(nil)
+
+	before, cancelBefore := g.WithDrainDeadline(context.Background())
+	defer cancelBefore()
+	if _, ok := before.Deadline(); ok {
+		t.Fatal("expected no deadline before drain")
+	}
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	g.beginDrain(deadline.Add(time.Hour))
+
+	// Until commitDrain the deadline is only an estimate
+	early, cancelEarly := g.WithDrainDeadline(context.Background())
+	defer cancelEarly()
+	if _, ok := early.Deadline(); ok {
+		t.Fatal("expected no fixed deadline before the drain is committed")
+	}
+	if !g.commitDrain(deadline) {
+		t.Fatal("expected commit")
+	}
+
+	during, cancelDuring := g.WithDrainDeadline(context.Background())
+	defer cancelDuring()
+	if d, ok := during.Deadline(); !ok || !d.Equal(deadline) {
+		t.Fatalf("expected drain deadline %v, got %v (%v)", deadline, d, ok)
+	}
+
+	for _, ctx := range []context.Context{before, early, during} {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatal("expected context to be cancelled at the drain deadline")
+		}
+	}
+	if context.Cause(during) != ErrDrainDeadlineExceeded {
+		t.Fatalf("unexpected cause %v", context.Cause(during))
+	}
+}
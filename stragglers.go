@@ -0,0 +1,53 @@
+package gracewrap
+
+import "time"
+
+// DrainTimeoutPolicy controls requests still in flight when DrainTimeout
+// expires.
+type DrainTimeoutPolicy int
+
+const (
+	// DrainTimeoutAbandon logs the stragglers and carries on with shutdown;
+	// they are cut off when the process exits (default).
+	DrainTimeoutAbandon DrainTimeoutPolicy = iota
+	// DrainTimeoutForceCancelContexts cancels the stragglers' contexts with
+	// ErrDrainDeadlineExceeded, then waits up to HardStopTimeout for their
+	// handlers to return, so they can roll back or answer cleanly.
+	DrainTimeoutForceCancelContexts
+	// DrainTimeoutLingerUntilHardStop keeps waiting for the stragglers, with
+	// their contexts intact, until HardStopTimeout has passed as well.
+	DrainTimeoutLingerUntilHardStop
+)
+
+// handleStragglers applies Config.DrainTimeoutPolicy to the requests still
+// in flight after the drain deadline, waiting at most hardStop. It returns
+// how much of the hard stop budget it used.
+func (g *Graceful) handleStragglers(hardStop time.Duration) time.Duration {
+	start := time.Now()
+	n := g.inflightCount()
+
+	switch g.config.DrainTimeoutPolicy {
+	case DrainTimeoutForceCancelContexts:
+		for _, e := range g.activeRequests() {
+			e.cancel(ErrDrainDeadlineExceeded)
+		}
+		g.logger.Printf("Cancelled %d straggling request(s)", n)
+		g.observeStragglers("cancelled", n)
+		g.observeStragglers("abandoned", g.waitForInflight(start.Add(hardStop)))
+	case DrainTimeoutLingerUntilHardStop:
+		g.logger.Printf("Waiting up to %v for %d straggling request(s)", hardStop, n)
+		left := g.waitForInflight(start.Add(hardStop))
+		g.observeStragglers("finished_late", n-left)
+		g.observeStragglers("abandoned", left)
+	default:
+		g.observeStragglers("abandoned", n)
+	}
+	return time.Since(start)
+}
+
+// observeStragglers counts n straggling requests with the given outcome.
+func (g *Graceful) observeStragglers(outcome string, n int64) {
+	if g.metrics != nil && n > 0 {
+		g.metrics.addStragglers(outcome, n)
+	}
+}
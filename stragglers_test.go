@@ -0,0 +1,78 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stragglerGraceful returns a Graceful whose drain deadline passes while a
+// shutdown hook is still running, so the straggler policy applies.
+func stragglerGraceful(t *testing.T, policy DrainTimeoutPolicy) *Graceful {
+	t.Helper()
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.DrainTimeout = 50 * time.Millisecond
+	g.config.DrainTimeoutPolicy = policy
+	g.OnShutdown("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	return g
+}
+
+func TestDrainTimeoutForceCancel(t *testing.T) {
+	g := stragglerGraceful(t, DrainTimeoutForceCancelContexts)
+
+	ctx, done := g.trackRequest(context.Background(), "http", "GET", "/stuck")
+	cause := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		cause <- context.Cause(ctx)
+		done()
+	}()
+
+	g.config.HardStopTimeout = 200 * time.Millisecond
+	g.Shutdown()
+	if err := <-cause; !errors.Is(err, ErrDrainDeadlineExceeded) {
+		t.Fatalf("expected ErrDrainDeadlineExceeded, got %v", err)
+	}
+	assertMetric(t, g, `gracewrap_drain_stragglers_total{outcome="cancelled"} 1`)
+}
+
+func TestDrainTimeoutLinger(t *testing.T) {
+	g := stragglerGraceful(t, DrainTimeoutLingerUntilHardStop)
+
+	g.config.HardStopTimeout = 300 * time.Millisecond
+	ctx, done := g.trackRequest(context.Background(), "http", "GET", "/slow")
+	time.AfterFunc(150*time.Millisecond, done)
+
+	g.Shutdown()
+	if err := context.Cause(ctx); errors.Is(err, ErrDrainDeadlineExceeded) {
+		t.Fatalf("expected the lingering request not to be cancelled, got %v", err)
+	}
+	assertMetric(t, g, `gracewrap_drain_stragglers_total{outcome="finished_late"} 1`)
+}
+
+func TestDrainTimeoutAbandon(t *testing.T) {
+	g := stragglerGraceful(t, DrainTimeoutAbandon)
+	g.config.HardStopTimeout = 0
+
+	_, done := g.trackRequest(context.Background(), "http", "GET", "/stuck")
+	defer done()
+
+	g.Shutdown()
+	assertMetric(t, g, `gracewrap_drain_stragglers_total{outcome="abandoned"} 1`)
+}
+
+func assertMetric(t *testing.T, g *Graceful, want string) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), want) {
+		t.Fatalf("expected %s in metrics, got:\n%s", want, rr.Body.String())
+	}
+}
@@ -0,0 +1,180 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// TerminationSource is a way for the platform to ask the process to stop:
+// a signal, a sentinel file, an IIS ANCM shutdown event, an orchestrator
+// callback. Wait starts shutdown when any configured source fires.
+type TerminationSource interface {
+	// Wait blocks until termination is requested, returning a short
+	// description of the trigger, or until ctx is done.
+	Wait(ctx context.Context) (reason string, err error)
+}
+
+// TerminationFunc adapts a function to a TerminationSource, e.g. one polling
+// a cloud provider's instance-stop or spot-interruption endpoint.
+type TerminationFunc func(ctx context.Context) (string, error)
+
+// Wait implements TerminationSource.
+func (f TerminationFunc) Wait(ctx context.Context) (string, error) {
+	return f(ctx)
+}
+
+// defaultShutdownSignals start shutdown unless Config.ShutdownSignals is set.
+var defaultShutdownSignals = []os.Signal{syscall.SIGTERM, syscall.SIGINT}
+
+// shutdownSignals returns the signals that start shutdown.
+func (g *Graceful) shutdownSignals() []os.Signal {
+	if len(g.config.ShutdownSignals) > 0 {
+		return g.config.ShutdownSignals
+	}
+	return defaultShutdownSignals
+}
+
+// SignalSource fires on any of sigs (SIGTERM and SIGINT if none are given).
+// Wait always uses it for Config.ShutdownSignals, alongside
+// Config.TerminationSources.
+//
+// On Windows, Go delivers Ctrl+C and Ctrl+Break as SIGINT and console close,
+// logoff and system shutdown events as SIGTERM, so the defaults apply there
+// too. When the process runs as a Windows service, Wait also answers the
+// Service Control Manager's Stop and Shutdown requests, reporting the
+// service as stopped once shutdown has completed.
+func SignalSource(sigs ...os.Signal) TerminationSource {
+	if len(sigs) == 0 {
+		sigs = defaultShutdownSignals
+	}
+	return TerminationFunc(func(ctx context.Context) (string, error) {
+		ch := make(chan os.Signal, 2)
+		signal.Notify(ch, sigs...)
+		select {
+		case sig := <-ch:
+			// Keep the signals captured so a repeated one does not kill
+			// the process mid-drain
+			return fmt.Sprintf("signal %v", sig), nil
+		case <-ctx.Done():
+			signal.Stop(ch)
+			return "", ctx.Err()
+		}
+	})
+}
+
+// FileSource fires once path exists, checking every interval (defaults to
+// 1s). A preStop hook or container orchestration script can request a
+// drain by creating the file, where signals are unavailable or unreliable.
+func FileSource(path string, interval time.Duration) TerminationSource {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	return TerminationFunc(func(ctx context.Context) (string, error) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := os.Stat(path); err == nil {
+				return "file " + path, nil
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+	})
+}
+
+// ANCMSource fires when the IIS ASP.NET Core Module asks an out-of-process
+// app to shut down, which it does over HTTP instead of a signal. Mount it
+// where ANCM posts its events:
+//
+//	src := gracewrap.NewANCMSource()
+//	mux.Handle("/iisintegration", src)
+//	cfg.TerminationSources = append(cfg.TerminationSources, src)
+type ANCMSource struct {
+	token string
+	once  sync.Once
+	fired chan struct{}
+}
+
+// NewANCMSource returns an ANCMSource accepting events carrying the pairing
+// token ANCM passes in the ASPNETCORE_TOKEN environment variable.
+func NewANCMSource() *ANCMSource {
+	return &ANCMSource{token: os.Getenv("ASPNETCORE_TOKEN"), fired: make(chan struct{})}
+}
+
+// ServeHTTP accepts ANCM shutdown events.
+func (s *ANCMSource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.Header.Get("MS-ASPNETCORE-EVENT") != "shutdown" {
+		http.Error(w, "unsupported event", http.StatusBadRequest)
+		return
+	}
+	if s.token != "" && r.Header.Get("MS-ASPNETCORE-TOKEN") != s.token {
+		http.Error(w, "invalid token", http.StatusForbidden)
+		return
+	}
+	s.once.Do(func() { close(s.fired) })
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Wait implements TerminationSource.
+func (s *ANCMSource) Wait(ctx context.Context) (string, error) {
+	select {
+	case <-s.fired:
+		return "ANCM shutdown event", nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// escalateOnSignal forces the shutdown about to run when another shutdown
+// signal arrives, until the returned func is called (see
+// Config.DisableSignalEscalation).
+func (g *Graceful) escalateOnSignal() (stop func()) {
+	if g.config.DisableSignalEscalation {
+		return func() {}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, g.shutdownSignals()...)
+	done := make(chan struct{})
+	go func() {
+		defer signal.Stop(ch)
+		select {
+		case sig := <-ch:
+			g.logger.Printf("Received signal %v during shutdown; forcing shutdown", sig)
+			g.ForceShutdown()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// watchTermination waits on the signal source and every configured source,
+// sending the first trigger's reason. Sources that fail are logged and
+// ignored. Watching stops when ctx is done.
+func (g *Graceful) watchTermination(ctx context.Context) <-chan string {
+	sources := append([]TerminationSource{SignalSource(g.config.ShutdownSignals...)}, g.platformSources()...)
+	sources = append(sources, g.config.TerminationSources...)
+	triggered := make(chan string, len(sources))
+	for _, src := range sources {
+		go func(src TerminationSource) {
+			reason, err := src.Wait(ctx)
+			if err != nil {
+				if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+					g.logger.Printf("Termination source failed: %v", err)
+				}
+				return
+			}
+			triggered <- reason
+		}(src)
+	}
+	return triggered
+}
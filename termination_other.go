@@ -0,0 +1,9 @@
+//go:build !windows
+
+package gracewrap
+
+// platformSources returns platform termination sources beyond signals; there
+// are none outside Windows.
+func (g *Graceful) platformSources() []TerminationSource {
+	return nil
+}
@@ -0,0 +1,72 @@
+package gracewrap
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWaitStopsOnFileSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drain")
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.TerminationSources = []TerminationSource{FileSource(path, 10*time.Millisecond)}
+
+	done := make(chan error, 1)
+	go func() { done <- g.Wait(context.Background()) }()
+
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Wait did not return after the sentinel file appeared")
+	}
+	if g.Ready() {
+		t.Fatal("expected not ready after shutdown")
+	}
+}
+
+func TestANCMSource(t *testing.T) {
+	t.Setenv("ASPNETCORE_TOKEN", "secret")
+	src := NewANCMSource()
+
+	post := func(event, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/iisintegration", nil)
+		req.Header.Set("MS-ASPNETCORE-EVENT", event)
+		req.Header.Set("MS-ASPNETCORE-TOKEN", token)
+		rr := httptest.NewRecorder()
+		src.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := post("shutdown", "wrong"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a bad token, got %d", code)
+	}
+	if code := post("ping", "secret"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for another event, got %d", code)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := src.Wait(ctx); err == nil {
+		t.Fatal("expected the source not to fire before a shutdown event")
+	}
+
+	if code := post("shutdown", "secret"); code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", code)
+	}
+	reason, err := src.Wait(context.Background())
+	if err != nil || reason == "" {
+		t.Fatalf("expected the source to fire, got %q, %v", reason, err)
+	}
+}
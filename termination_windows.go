@@ -0,0 +1,75 @@
+//go:build windows
+
+package gracewrap
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// platformSources returns the Service Control Manager source when the
+// process runs as a Windows service.
+func (g *Graceful) platformSources() []TerminationSource {
+	if ok, err := svc.IsWindowsService(); err != nil || !ok {
+		return nil
+	}
+	return []TerminationSource{g.serviceSource()}
+}
+
+// serviceSource registers the process with the Service Control Manager (once)
+// and fires when it asks the service to stop or the system shuts down.
+func (g *Graceful) serviceSource() TerminationSource {
+	g.service.once.Do(func() {
+		g.service.stops = make(chan string, 1)
+		go func() {
+			if err := svc.Run("", &serviceHandler{g: g}); err != nil {
+				g.logger.Printf("Windows service control failed: %v", err)
+			}
+		}()
+	})
+	return TerminationFunc(func(ctx context.Context) (string, error) {
+		select {
+		case reason := <-g.service.stops:
+			return reason, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+}
+
+// serviceHandler answers Service Control Manager requests. It reports the
+// service as stopped only once shutdown has completed, so the SCM does not
+// kill the process mid-drain.
+type serviceHandler struct {
+	g *Graceful
+}
+
+// Execute implements svc.Handler.
+func (h *serviceHandler) Execute(_ []string, r <-chan svc.ChangeRequest, s chan<- svc.Status) (bool, uint32) {
+	s <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	cfg := h.g.config
+	hint := cfg.LoadBalancerDelay + cfg.DrainTimeout + cfg.HardStopTimeout + time.Second
+	for {
+		select {
+		case c := <-r:
+			switch c.Cmd {
+			case svc.Interrogate:
+				s <- c.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				s <- svc.Status{State: svc.StopPending, WaitHint: uint32(hint / time.Millisecond)}
+				reason := "service stop request"
+				if c.Cmd == svc.Shutdown {
+					reason = "system shutdown"
+				}
+				select {
+				case h.g.service.stops <- reason:
+				default:
+				}
+			}
+		case <-h.g.done:
+			return false, 0
+		}
+	}
+}
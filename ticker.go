@@ -0,0 +1,102 @@
+package gracewrap
+
+import (
+	"context"
+	"time"
+)
+
+// Every runs fn every d until drain begins. No new ticks start once drain has
+// started; a tick that is already running may finish within the drain window,
+// and shutdown waits for it before proceeding. If the drain is cancelled (see
+// CancelDrain) the ticks resume. The context passed to fn is cancelled at the
+// drain deadline. Errors returned by fn are logged.
+func (g *Graceful) Every(d time.Duration, fn func(ctx context.Context) error) {
+	g.taskStarted()
+	go func() {
+		ticker := time.NewTicker(d)
+		defer ticker.Stop()
+
+		for {
+			g.tickUntilDrain(ticker, fn)
+			cancelled := g.drainCancelled()
+			g.taskStopped()
+
+			select {
+			case <-cancelled:
+				g.taskStarted()
+				ticker.Reset(d)
+			case <-g.done:
+				return
+			}
+		}
+	}()
+}
+
+// tickUntilDrain runs fn on every tick until drain begins.
+func (g *Graceful) tickUntilDrain(ticker *time.Ticker, fn func(ctx context.Context) error) {
+	for {
+		select {
+		case <-g.drainStarted():
+			return
+		case <-ticker.C:
+		}
+
+		// Drain may have started while we were waiting on the ticker
+		select {
+		case <-g.drainStarted():
+			return
+		default:
+		}
+
+		ctx, cancel := g.untilDrainDeadline(context.Background())
+		if err := fn(ctx); err != nil {
+			g.logger.Printf("Periodic task error: %v", err)
+		}
+		cancel()
+	}
+}
+
+// taskStarted counts a task that ticks until drain begins.
+func (g *Graceful) taskStarted() {
+	g.tasks.mu.Lock()
+	defer g.tasks.mu.Unlock()
+	g.tasks.n++
+	if g.tasks.n == 1 {
+		g.tasks.idle = make(chan struct{})
+	}
+}
+
+// taskStopped uncounts a task once drain has stopped its ticks.
+func (g *Graceful) taskStopped() {
+	g.tasks.mu.Lock()
+	defer g.tasks.mu.Unlock()
+	g.tasks.n--
+	if g.tasks.n == 0 {
+		close(g.tasks.idle)
+	}
+}
+
+// waitForTasks waits for background tasks to stop ticking, up to deadline or
+// until the shutdown is cut short.
+func (g *Graceful) waitForTasks(deadline time.Time) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	for {
+		g.tasks.mu.Lock()
+		n, idle := g.tasks.n, g.tasks.idle
+		g.tasks.mu.Unlock()
+		if n == 0 {
+			return true
+		}
+
+		select {
+		case <-idle:
+			// Re-check: a task may have resumed after a cancelled drain
+		case <-timer.C:
+			return false
+		case <-g.cut.ch:
+			return false
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package gracewrap
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEveryStopsAtDrainAndFinishesRunningTick(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.DrainTimeout = time.Second
+	g.config.HardStopTimeout = 0
+
+	var ticks, finished atomic.Int32
+	running := make(chan struct{}, 1)
+	g.Every(10*time.Millisecond, func(ctx context.Context) error {
+		if ticks.Add(1) == 1 {
+			running <- struct{}{}
+			time.Sleep(50 * time.Millisecond)
+		}
+		finished.Add(1)
+		return nil
+	})
+
+	<-running
+	g.Shutdown()
+
+	if ticks.Load() != finished.Load() {
+		t.Fatalf("shutdown returned before running tick finished: %d started, %d finished", ticks.Load(), finished.Load())
+	}
+	after := ticks.Load()
+	time.Sleep(30 * time.Millisecond)
+	if ticks.Load() != after {
+		t.Fatalf("tick ran after drain started")
+	}
+}
+
+func TestEveryResumesAfterCancelledDrain(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 5 * time.Second
+	g.config.HardStopTimeout = 0
+
+	var ticks atomic.Int32
+	g.Every(5*time.Millisecond, func(ctx context.Context) error {
+		ticks.Add(1)
+		return nil
+	})
+
+	go g.Shutdown()
+	<-g.drainStarted()
+	time.Sleep(20 * time.Millisecond)
+	stopped := ticks.Load()
+	time.Sleep(30 * time.Millisecond)
+	if ticks.Load() != stopped {
+		t.Fatal("tick ran while draining")
+	}
+
+	if err := g.CancelDrain(); err != nil {
+		t.Fatalf("cancel drain: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for ticks.Load() == stopped {
+		if time.Now().After(deadline) {
+			t.Fatal("expected ticks to resume after CancelDrain")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The next drain still waits for the task to stop
+	g.config.LoadBalancerDelay = 0
+	g.Shutdown()
+	after := ticks.Load()
+	time.Sleep(30 * time.Millisecond)
+	if ticks.Load() != after {
+		t.Fatal("tick ran after shutdown")
+	}
+}
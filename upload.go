@@ -0,0 +1,169 @@
+package gracewrap
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// UploadDrainPolicy controls requests still uploading their body when drain starts.
+type UploadDrainPolicy int
+
+const (
+	// UploadDrainAllow lets uploads continue until the server shuts down (default).
+	UploadDrainAllow UploadDrainPolicy = iota
+	// UploadDrainReject stops reading the body once drain starts and answers
+	// 503 with Connection: close. Clients waiting on 100-continue never get
+	// the go-ahead, so they do not send the body at all.
+	UploadDrainReject
+	// UploadDrainDeadline lets uploads continue for UploadDrainTimeout after
+	// drain starts, then cuts them off like UploadDrainReject.
+	UploadDrainDeadline
+)
+
+// errUploadCut is returned from request body reads cut off by drain.
+var errUploadCut = errors.New("gracewrap: upload cut off by drain")
+
+// uploadGuard wraps a request body and its response writer so a body read
+// cut off by drain turns into a 503 response.
+type uploadGuard struct {
+	http.ResponseWriter
+	body     io.ReadCloser
+	graceful *Graceful
+
+	mu          sync.Mutex
+	wroteHeader bool
+	cut         bool
+	rejected    bool // the guard wrote the 503 itself
+}
+
+// guardUpload installs the upload drain policy on the request, if any.
+func (g *Graceful) guardUpload(w http.ResponseWriter, r *http.Request) (http.ResponseWriter, *http.Request) {
+	if g.config.UploadDrainPolicy == UploadDrainAllow || r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+		return w, r
+	}
+	u := &uploadGuard{ResponseWriter: w, body: r.Body, graceful: g}
+	r.Body = u
+	if f, ok := w.(http.Flusher); ok {
+		return flushingUploadGuard{uploadGuard: u, f: f}, r
+	}
+	return u, r
+}
+
+// Read implements io.Reader, cutting the upload off according to the policy.
+func (u *uploadGuard) Read(p []byte) (int, error) {
+	if u.expired() {
+		u.cutOff()
+		return 0, errUploadCut
+	}
+	return u.body.Read(p)
+}
+
+// Close implements io.Closer.
+func (u *uploadGuard) Close() error {
+	return u.body.Close()
+}
+
+// expired reports whether the upload may no longer be read.
+func (u *uploadGuard) expired() bool {
+	g := u.graceful
+	select {
+	case <-g.drainStarted():
+	default:
+		return false
+	}
+	if g.config.UploadDrainPolicy == UploadDrainReject {
+		return true
+	}
+	return time.Since(g.drainStartedAt()) >= g.config.UploadDrainTimeout
+}
+
+// cutOff answers 503 (once) unless the handler already started responding.
+func (u *uploadGuard) cutOff() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.cut {
+		return
+	}
+	u.cut = true
+	if u.graceful.metrics != nil {
+		u.graceful.metrics.incUploadsCut()
+	}
+	if u.wroteHeader {
+		return
+	}
+	u.wroteHeader = true
+	u.rejected = true
+	u.ResponseWriter.Header().Set("Connection", "close")
+	http.Error(u.ResponseWriter, "draining", http.StatusServiceUnavailable)
+}
+
+// WriteHeader implements http.ResponseWriter, ignoring it once a response has started.
+func (u *uploadGuard) WriteHeader(code int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.wroteHeader {
+		return
+	}
+	u.wroteHeader = true
+	u.ResponseWriter.WriteHeader(code)
+}
+
+// Write implements http.ResponseWriter, discarding writes after the guard
+// answered 503 so they do not end up in its body. A response the handler
+// started before the cut-off is passed through.
+func (u *uploadGuard) Write(p []byte) (int, error) {
+	u.mu.Lock()
+	rejected := u.rejected
+	u.wroteHeader = true
+	u.mu.Unlock()
+	if rejected {
+		return len(p), nil
+	}
+	return u.ResponseWriter.Write(p)
+}
+
+// Hijack implements http.Hijacker. The guard no longer answers 503 once the
+// connection has been taken over.
+func (u *uploadGuard) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := u.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	u.mu.Lock()
+	u.wroteHeader = true
+	u.mu.Unlock()
+	return conn, rw, nil
+}
+
+// Push implements http.Pusher.
+func (u *uploadGuard) Push(target string, opts *http.PushOptions) error {
+	if p, ok := u.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
+
+// flushingUploadGuard is an uploadGuard over a writer that can flush.
+type flushingUploadGuard struct {
+	*uploadGuard
+	f http.Flusher
+}
+
+// Flush implements http.Flusher.
+func (fu flushingUploadGuard) Flush() {
+	fu.f.Flush()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (u *uploadGuard) Unwrap() http.ResponseWriter {
+	return u.ResponseWriter
+}
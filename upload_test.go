@@ -0,0 +1,128 @@
+package gracewrap
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUploadDrainRejectCutsBody(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.UploadDrainPolicy = UploadDrainReject
+
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		g.beginDrain(time.Now().Add(time.Second))
+		if _, err := io.ReadAll(r.Body); err == nil {
+			t.Error("expected body read to be cut off")
+		}
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("payload")))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+	if rr.Header().Get("Connection") != "close" {
+		t.Fatalf("expected Connection: close")
+	}
+
+	mrr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(mrr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(mrr.Body.String(), "gracewrap_uploads_cut_total 1") {
+		t.Fatalf("expected uploads cut metric")
+	}
+}
+
+func TestUploadDrainDeadlineAllowsCompletion(t *testing.T) {
+	g := New(nil)
+	g.config.UploadDrainPolicy = UploadDrainDeadline
+	g.config.UploadDrainTimeout = time.Minute
+	g.beginDrain(time.Now().Add(time.Minute))
+
+	var body []byte
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("payload")))
+	if string(body) != "payload" || rr.Code != http.StatusOK {
+		t.Fatalf("expected upload to complete, got %q (%d)", body, rr.Code)
+	}
+}
+
+func TestUploadGuardFlusherOnlyWhenSupported(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.UploadDrainPolicy = UploadDrainReject
+
+	var canFlush bool
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, canFlush = w.(http.Flusher)
+	}))
+
+	h.ServeHTTP(&plainWriter{header: http.Header{}}, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("payload")))
+	if canFlush {
+		t.Fatal("expected no http.Flusher over a writer that cannot flush")
+	}
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("payload")))
+	if !canFlush {
+		t.Fatal("expected http.Flusher over a writer that can flush")
+	}
+}
+
+func TestUploadCutAfterResponseStartedKeepsWrites(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.UploadDrainPolicy = UploadDrainReject
+
+	h := g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial "))
+		g.beginDrain(time.Now().Add(time.Second))
+		if _, err := io.ReadAll(r.Body); err == nil {
+			t.Error("expected body read to be cut off")
+		}
+		if _, err := w.Write([]byte("rest")); err != nil {
+			t.Errorf("write after cut-off: %v", err)
+		}
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("payload")))
+	if rr.Code != http.StatusOK || rr.Body.String() != "partial rest" {
+		t.Fatalf("expected the handler's response to pass through, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestUploadGuardForwardsHijack(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.UploadDrainPolicy = UploadDrainReject
+
+	srv := httptest.NewServer(g.httpMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Error("expected the upload guard to implement http.Hijacker")
+			return
+		}
+		conn, rw, err := hj.Hijack()
+		if err != nil {
+			t.Errorf("hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		rw.Flush()
+	})))
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hijacked" {
+		t.Fatalf("expected hijacked response, got %q", body)
+	}
+}
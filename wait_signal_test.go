@@ -30,3 +30,34 @@ func TestWaitHandlesSignal(t *testing.T) {
 		t.Skip("signal delivery not available in this environment")
 	}
 }
+
+func TestSecondSignalForcesShutdown(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 5 * time.Second
+	g.config.HardStopTimeout = 5 * time.Second
+
+	done := make(chan struct{})
+	go func() {
+		_ = g.Wait(context.Background())
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	p, _ := os.FindProcess(os.Getpid())
+	_ = p.Signal(syscall.SIGINT)
+
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for g.Ready() {
+		if time.Now().After(deadline) {
+			t.Skip("signal delivery not available in this environment")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	_ = p.Signal(syscall.SIGINT)
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("second signal did not force shutdown")
+	}
+}
@@ -0,0 +1,57 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWaitAfterShutdown(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.Shutdown()
+
+	done := make(chan error, 1)
+	go func() { done <- g.Wait(context.Background()) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Wait blocked after Shutdown had completed")
+	}
+}
+
+func TestConcurrentWaitCallers(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 20 * time.Millisecond
+	g.config.HardStopTimeout = 0
+	g.OnShutdown("flush", func(ctx context.Context) error { return errors.New("disk full") })
+
+	const callers = 3
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		go func() { errs <- g.Wait(context.Background()) }()
+	}
+	go g.Shutdown()
+
+	var first error
+	for i := 0; i < callers; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, ErrHookFailed) {
+				t.Fatalf("expected ErrHookFailed, got %v", err)
+			}
+			if first == nil {
+				first = err
+			} else if err != first {
+				t.Fatalf("expected the same error for every caller, got %v and %v", first, err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Wait did not return after Shutdown")
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package gracewrap
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// NodeWebsocket groups tracked WebSocket connections in the shutdown order.
+const NodeWebsocket = "websocket"
+
+// CloseGoingAway is the WebSocket close code sent to clients at drain (RFC 6455).
+const CloseGoingAway = 1001
+
+// WebsocketCloser sends a WebSocket close frame with the given code and
+// reason, e.g. with gorilla/websocket:
+//
+//	func(code int, reason string) error {
+//		msg := websocket.FormatCloseMessage(code, reason)
+//		return conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+//	}
+type WebsocketCloser func(code int, reason string) error
+
+// trackedWebsocket is a WebSocket connection closed gracefully at drain.
+type trackedWebsocket struct {
+	conn    io.Closer
+	closeFn WebsocketCloser
+	gone    chan struct{}
+	once    sync.Once
+}
+
+// TrackWebsocket registers a WebSocket connection for graceful close at drain:
+// every tracked connection gets a 1001 Going Away close frame, clients get
+// Config.WebsocketCloseGrace to close on their side, and remaining
+// connections are then closed outright. Call the returned func when the
+// connection ends on its own.
+func (g *Graceful) TrackWebsocket(conn io.Closer, closeFn WebsocketCloser) (untrack func()) {
+	ws := &trackedWebsocket{conn: conn, closeFn: closeFn, gone: make(chan struct{})}
+
+	g.websockets.mu.Lock()
+	if g.websockets.m == nil {
+		g.websockets.m = make(map[*trackedWebsocket]struct{})
+	}
+	g.websockets.m[ws] = struct{}{}
+	g.websockets.mu.Unlock()
+
+	return func() {
+		g.websockets.mu.Lock()
+		delete(g.websockets.m, ws)
+		g.websockets.mu.Unlock()
+		ws.once.Do(func() { close(ws.gone) })
+	}
+}
+
+// closeWebsockets sends Going Away to every tracked WebSocket, waits for the
+// grace window (bounded by ctx) and then closes the stragglers.
+func (g *Graceful) closeWebsockets(ctx context.Context) {
+	g.websockets.mu.Lock()
+	sockets := make([]*trackedWebsocket, 0, len(g.websockets.m))
+	for ws := range g.websockets.m {
+		sockets = append(sockets, ws)
+	}
+	g.websockets.mu.Unlock()
+
+	if len(sockets) == 0 {
+		return
+	}
+	g.logger.Printf("Sending Going Away to %d WebSocket connection(s)", len(sockets))
+
+	for _, ws := range sockets {
+		if ws.closeFn == nil {
+			continue
+		}
+		if err := ws.closeFn(CloseGoingAway, "server shutting down"); err != nil {
+			g.logger.Printf("WebSocket close frame error: %v", err)
+		}
+	}
+
+	grace := g.config.WebsocketCloseGrace
+	if grace <= 0 {
+		grace = 5 * time.Second
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+
+	forced := 0
+	for _, ws := range sockets {
+		select {
+		case <-ws.gone:
+			continue
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		// Grace window over: close this and every remaining connection
+		for _, rest := range sockets {
+			select {
+			case <-rest.gone:
+			default:
+				_ = rest.conn.Close()
+				forced++
+			}
+		}
+		break
+	}
+	if forced > 0 {
+		g.logger.Printf("Closed %d WebSocket connection(s) after the grace window", forced)
+	}
+}
@@ -0,0 +1,50 @@
+package gracewrap
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type fakeWSConn struct{ closed atomic.Bool }
+
+func (c *fakeWSConn) Close() error { c.closed.Store(true); return nil }
+
+func TestTrackWebsocketGoingAwayAndGrace(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+	g.config.WebsocketCloseGrace = 50 * time.Millisecond
+
+	var codes []int
+	polite := &fakeWSConn{}
+	var untrackPolite func()
+	untrackPolite = g.TrackWebsocket(polite, func(code int, reason string) error {
+		codes = append(codes, code)
+		// A well-behaved client closes right away
+		go untrackPolite()
+		return nil
+	})
+
+	stubborn := &fakeWSConn{}
+	g.TrackWebsocket(stubborn, func(code int, reason string) error {
+		codes = append(codes, code)
+		return nil
+	})
+
+	start := time.Now()
+	g.Shutdown()
+
+	if len(codes) != 2 || codes[0] != CloseGoingAway || codes[1] != CloseGoingAway {
+		t.Fatalf("expected Going Away frames, got %v", codes)
+	}
+	if polite.closed.Load() {
+		t.Fatal("polite connection should have been left to the client")
+	}
+	if !stubborn.closed.Load() {
+		t.Fatal("stubborn connection should be closed after the grace window")
+	}
+	if time.Since(start) < 50*time.Millisecond {
+		t.Fatal("expected shutdown to honor the grace window")
+	}
+}
@@ -0,0 +1,167 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrPoolDraining is returned by WorkerPool.Submit once drain has started.
+var ErrPoolDraining = errors.New("gracewrap: worker pool is draining")
+
+// WorkerPool is a fixed-size in-process job queue with drain semantics:
+// Submit refuses new jobs once drain starts, and queued jobs keep running
+// until the drain deadline.
+type WorkerPool struct {
+	graceful *Graceful
+	jobs     chan func(ctx context.Context)
+	ctx      context.Context
+	cancel   context.CancelFunc
+
+	mu      sync.RWMutex
+	closed  bool
+	closing chan struct{}  // closed when the pool stops accepting jobs
+	sending sync.WaitGroup // Submit calls that may still send on jobs
+	wg      sync.WaitGroup
+
+	depth prometheus.Gauge
+	busy  prometheus.Gauge
+}
+
+// NewWorkerPool starts a pool of n workers. Queue depth and busy workers are
+// exported on the metrics registry when metrics are enabled, labelled by the
+// pool's creation index.
+func (g *Graceful) NewWorkerPool(n int) *WorkerPool {
+	if n < 1 {
+		n = 1
+	}
+	ctx, cancel := g.untilDrainDeadline(context.Background())
+	p := &WorkerPool{
+		graceful: g,
+		jobs:     make(chan func(ctx context.Context), n*16),
+		ctx:      ctx,
+		cancel:   cancel,
+		closing:  make(chan struct{}),
+	}
+
+	g.pools.mu.Lock()
+	index := len(g.pools.list)
+	g.pools.list = append(g.pools.list, p)
+	g.pools.mu.Unlock()
+
+	if g.metrics != nil {
+		p.depth, p.busy = g.metrics.workerPoolGauges(strconv.Itoa(index), n)
+	}
+
+	for i := 0; i < n; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+
+	return p
+}
+
+// Submit queues job for execution. The job's context is cancelled at the
+// drain deadline. Submit blocks while the queue is full and returns
+// ErrPoolDraining once drain has started.
+func (p *WorkerPool) Submit(job func(ctx context.Context)) error {
+	drain := p.graceful.drainStarted()
+	select {
+	case <-drain:
+		return ErrPoolDraining
+	default:
+	}
+
+	p.mu.RLock()
+	if p.closed {
+		p.mu.RUnlock()
+		return ErrPoolDraining
+	}
+	p.sending.Add(1)
+	p.mu.RUnlock()
+	defer p.sending.Done()
+
+	// Count the job before a worker can pick it up and decrement the gauge
+	if p.depth != nil {
+		p.depth.Inc()
+	}
+	select {
+	case p.jobs <- job:
+		return nil
+	case <-p.closing:
+	case <-drain:
+	}
+	if p.depth != nil {
+		p.depth.Dec()
+	}
+	return ErrPoolDraining
+}
+
+// work executes queued jobs until the queue is closed and empty.
+func (p *WorkerPool) work() {
+	defer p.wg.Done()
+	for job := range p.jobs {
+		if p.depth != nil {
+			p.depth.Dec()
+		}
+		if p.busy != nil {
+			p.busy.Inc()
+		}
+		job(p.ctx)
+		if p.busy != nil {
+			p.busy.Dec()
+		}
+	}
+}
+
+// close stops accepting jobs and waits for queued jobs until deadline.
+func (p *WorkerPool) close(deadline time.Time) bool {
+	timer := time.NewTimer(time.Until(deadline))
+	defer timer.Stop()
+
+	p.mu.Lock()
+	first := !p.closed
+	if first {
+		p.closed = true
+		close(p.closing)
+	}
+	p.mu.Unlock()
+	if first {
+		// Blocked Submit calls give up on closing; wait for them before
+		// closing the queue they send on
+		p.sending.Wait()
+		close(p.jobs)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return true
+	case <-timer.C:
+		p.cancel()
+		return false
+	}
+}
+
+// closePools closes every worker pool and waits for queued jobs until deadline.
+func (g *Graceful) closePools(deadline time.Time) {
+	g.pools.mu.Lock()
+	pools := g.pools.list
+	g.pools.mu.Unlock()
+
+	for _, p := range pools {
+		if !p.close(deadline) {
+			g.logger.Printf("Worker pool jobs did not complete before deadline")
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package gracewrap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerPoolDrainsQueuedJobs(t *testing.T) {
+	g := newTestGraceful(t)
+	g.config.LoadBalancerDelay = 0
+	g.config.DrainTimeout = time.Second
+	g.config.HardStopTimeout = 0
+
+	p := g.NewWorkerPool(1)
+	var done atomic.Int32
+	for i := 0; i < 3; i++ {
+		if err := p.Submit(func(ctx context.Context) {
+			time.Sleep(10 * time.Millisecond)
+			done.Add(1)
+		}); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+
+	g.Shutdown()
+
+	if done.Load() != 3 {
+		t.Fatalf("expected queued jobs to finish, got %d", done.Load())
+	}
+	if err := p.Submit(func(ctx context.Context) {}); !errors.Is(err, ErrPoolDraining) {
+		t.Fatalf("expected ErrPoolDraining, got %v", err)
+	}
+
+	rr := httptest.NewRecorder()
+	g.MetricsHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rr.Body.String(), `gracewrap_worker_pool_workers{pool="0"} 1`) {
+		t.Fatalf("expected worker pool metrics, got %s", rr.Body.String())
+	}
+}
+
+func TestWorkerPoolSubmitBlockedOnFullQueueReturnsAtDrain(t *testing.T) {
+	g := newTestGraceful(t)
+	p := g.NewWorkerPool(1)
+
+	release := make(chan struct{})
+	defer close(release)
+	running := make(chan struct{})
+	if err := p.Submit(func(ctx context.Context) { close(running); <-release }); err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+	<-running
+	for i := 0; i < cap(p.jobs); i++ {
+		if err := p.Submit(func(ctx context.Context) { <-release }); err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+	}
+
+	blocked := make(chan error, 1)
+	go func() { blocked <- p.Submit(func(ctx context.Context) {}) }()
+	select {
+	case err := <-blocked:
+		t.Fatalf("expected Submit to block on a full queue, got %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	deadline := time.Now().Add(50 * time.Millisecond)
+	g.beginDrain(deadline)
+	select {
+	case err := <-blocked:
+		if !errors.Is(err, ErrPoolDraining) {
+			t.Fatalf("expected ErrPoolDraining, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("blocked Submit did not return at drain start")
+	}
+
+	// Jobs stuck past the deadline do not hold up closing the pool
+	start := time.Now()
+	g.closePools(deadline)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatalf("closePools overran the drain deadline by %v", time.Since(deadline))
+	}
+}
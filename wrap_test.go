@@ -29,3 +29,41 @@ func TestWrapHTTP_StartsAndShutdowns(t *testing.T) {
 	go func() { _ = g.Wait(ctx) }()
 	g.Shutdown()
 }
+
+func TestWrapHTTPRejectsEmptyAddr(t *testing.T) {
+	g := New(nil)
+	if err := g.WrapHTTP(&http.Server{Handler: http.NotFoundHandler()}); err == nil {
+		t.Fatal("expected an error for an empty Addr")
+	}
+	if err := g.WrapHTTPTLS(&http.Server{}, "cert.pem", "key.pem"); err == nil {
+		t.Fatal("expected an error for an empty Addr")
+	}
+	if len(g.httpServers) != 0 {
+		t.Fatal("rejected servers must not be tracked")
+	}
+}
+
+func TestListenAndWrapHTTP(t *testing.T) {
+	g := New(nil)
+	g.config.LoadBalancerDelay = 0
+	g.config.HardStopTimeout = 0
+
+	_, addr, err := g.ListenAndWrapHTTP("127.0.0.1:0", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	if err != nil {
+		t.Fatalf("listen and wrap: %v", err)
+	}
+	defer g.Shutdown()
+
+	// The bind is synchronous: the address is usable immediately
+	resp, err := http.Get("http://" + addr)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	resp.Body.Close()
+
+	if _, _, err := g.ListenAndWrapHTTP(addr, http.NotFoundHandler()); err == nil {
+		t.Fatal("expected bind error for an address in use")
+	}
+}